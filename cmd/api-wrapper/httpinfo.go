@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -152,6 +154,121 @@ func getHTTPInfo() {
 		"acceptRanges": resp.Header.Get("Accept-Ranges") == "bytes",
 	}
 
+	if sha256, sha512 := parseDigestHeaders(resp.Header); sha256 != "" || sha512 != "" {
+		if sha256 != "" {
+			result["sha256"] = sha256
+		}
+		if sha512 != "" {
+			result["sha512"] = sha512
+		}
+	}
+
+	discoverSidecars(client, source, fileName, result)
+
 	data, _ := json.Marshal(result)
 	fmt.Println(string(data))
 }
+
+// parseDigestHeaders extracts sha-256/sha-512 values from the RFC 9530
+// Digest/Repr-Digest response headers, e.g. `Repr-Digest: sha-256=:base64:`.
+func parseDigestHeaders(header http.Header) (sha256Hex, sha512Hex string) {
+	digestRe := regexp.MustCompile(`(sha-256|sha-512)=:([^:]+):`)
+	for _, headerName := range []string{"Repr-Digest", "Digest"} {
+		value := header.Get(headerName)
+		if value == "" {
+			continue
+		}
+		for _, match := range digestRe.FindAllStringSubmatch(value, -1) {
+			decoded, err := base64.StdEncoding.DecodeString(match[2])
+			if err != nil {
+				continue
+			}
+			hexVal := hex.EncodeToString(decoded)
+			if match[1] == "sha-256" && sha256Hex == "" {
+				sha256Hex = hexVal
+			} else if match[1] == "sha-512" && sha512Hex == "" {
+				sha512Hex = hexVal
+			}
+		}
+	}
+	return
+}
+
+// discoverSidecars probes for checksum/signature/torrent files living
+// alongside source (same directory, based on the resolved filename) and, if
+// found, fetches and parses them into result so the caller can automatically
+// populate Options.SHA256 or switch to the torrent path.
+func discoverSidecars(client *http.Client, source, fileName string, result map[string]interface{}) {
+	baseURL, err := url.Parse(source)
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(baseURL.Path)
+
+	sidecarURL := func(name string) string {
+		u := *baseURL
+		u.Path = filepath.Join(dir, name)
+		return u.String()
+	}
+
+	for _, name := range []string{fileName + ".sha256", fileName + ".sha256sum", "SHA256SUMS"} {
+		body, ok := fetchSidecar(client, sidecarURL(name))
+		if !ok {
+			continue
+		}
+		if digest := parseSHA256SumsBody(string(body), fileName); digest != "" {
+			result["sha256"] = digest
+			result["checksumURL"] = sidecarURL(name)
+			break
+		}
+	}
+
+	if _, ok := fetchSidecar(client, sidecarURL(fileName+".asc")); ok {
+		result["signatureURL"] = sidecarURL(fileName + ".asc")
+	}
+
+	if _, ok := fetchSidecar(client, sidecarURL(fileName+".torrent")); ok {
+		result["torrentURL"] = sidecarURL(fileName + ".torrent")
+	}
+}
+
+// parseSHA256SumsBody scans a SHA256SUMS-style body ("<hex>  <filename>" per
+// line) for an entry matching fileName, falling back to the only line's hash
+// when the body is a bare "<file>.sha256"/".sha256sum" sidecar with one entry.
+func parseSHA256SumsBody(body, fileName string) string {
+	hexRe := regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !hexRe.MatchString(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			return strings.ToLower(fields[0])
+		}
+		if strings.TrimPrefix(fields[len(fields)-1], "*") == fileName {
+			return strings.ToLower(fields[0])
+		}
+	}
+	return ""
+}
+
+// fetchSidecar does a best-effort GET for sidecarURL, returning the body only
+// on a 2xx response.
+func fetchSidecar(client *http.Client, sidecarURL string) ([]byte, bool) {
+	resp, err := client.Get(sidecarURL)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
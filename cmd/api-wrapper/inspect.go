@@ -112,6 +112,7 @@ func inspectTorrent() {
 		"totalSize": totalSize,
 		"fileCount": len(files),
 		"files":     files,
+		"webSeeds":  mi.UrlList,
 	}
 
 	data, err := json.Marshal(result)
@@ -261,11 +262,12 @@ func inspectMagnetLink(magnetURL string) (map[string]interface{}, error) {
 		"totalSize": totalSize,
 		"fileCount": len(files),
 		"files":     files,
+		"webSeeds":  t.Metainfo().UrlList,
 	}
-	
+
 	// Remove torrent from client before returning (cleanup)
 	t.Drop()
-	
+
 	return result, nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -51,6 +52,9 @@ func main() {
 		btSequential   = flag.Bool("bt-sequential", false, "Sequential mode")
 		btKeepSeeding  = flag.Bool("bt-keep-seeding", false, "Keep seeding after download completes")
 		btPort         = flag.Int("bt-port", 0, "BitTorrent listen port (0 = use default/auto)")
+		webSeeds       = flag.String("webseeds", "", "Comma-separated BEP 19 webseed URLs to use as an HTTP fallback")
+		singleFileMode = flag.Bool("single-file-mode", false, "Write chunks directly into the output file instead of merging temp parts")
+		mirrors        = flag.String("mirrors", "", "Comma-separated equivalent URLs to dispatch chunks across via HRW hashing")
 		connectTimeout = flag.Int("connect-timeout", 15, "Connect timeout")
 		readTimeout    = flag.Int("read-timeout", 60, "Read timeout")
 		retries        = flag.Int("retries", 5, "Retries")
@@ -59,10 +63,19 @@ func main() {
 		httpInfo       = flag.Bool("http-info", false, "Get HTTP file info only")
 		speedTest      = flag.Bool("speedtest", false, "Run speed test")
 		testType       = flag.String("test-type", "full", "Speed test type: full, latency, download, upload")
+		rangeRead      = flag.Bool("range-read", false, "Fetch a byte range from source over HTTP and write it to stdout")
+		rangeOffset    = flag.Int64("range-offset", 0, "Byte offset to start the range read at")
+		rangeLength    = flag.Int64("range-length", 0, "Number of bytes to read; 0 reads to EOF")
 	)
 
 	flag.Parse()
 
+	// Handle range-read mode
+	if *rangeRead {
+		runRangeRead(*source, *rangeOffset, *rangeLength, downloader.Options{ConnectTimeout: *connectTimeout, ReadTimeout: *readTimeout, Retries: *retries})
+		return
+	}
+
 	// Handle speed test mode
 	if *speedTest {
 		// Pass test type to runSpeedTest via environment or modify runSpeedTest to accept it
@@ -106,6 +119,16 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	var webSeedList []string
+	if *webSeeds != "" {
+		webSeedList = strings.Split(*webSeeds, ",")
+	}
+
+	var mirrorList []string
+	if *mirrors != "" {
+		mirrorList = strings.Split(*mirrors, ",")
+	}
+
 	opts := downloader.Options{
 		Connections:    *connections,
 		ChunkSize:      chunkSizeBytes,
@@ -118,6 +141,9 @@ func main() {
 		BTSequential:   *btSequential,
 		BTKeepSeeding:  *btKeepSeeding,
 		BTPort:         *btPort,
+		WebSeeds:       webSeedList,
+		SingleFileMode: *singleFileMode,
+		Mirrors:        mirrorList,
 		Quiet:          true,
 		StatusReporter: reporter,
 		DownloadID:     *downloadID,
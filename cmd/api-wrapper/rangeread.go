@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/accelara/clidm/internal/downloader"
+)
+
+// runRangeRead fetches [offset, offset+length) from source over HTTP range
+// requests and writes the raw bytes to stdout. length <= 0 reads to EOF. This
+// is the one place HTTPRangeReader is actually reachable from outside the
+// downloader package, for callers that want a byte slice rather than a whole
+// file on disk (e.g. probing a remote archive's central directory).
+func runRangeRead(source string, offset, length int64, opts downloader.Options) {
+	if source == "" {
+		fmt.Fprintf(os.Stderr, "Error: source is required\n")
+		os.Exit(1)
+	}
+
+	r, err := downloader.NewHTTPRangeReader(source, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	if offset > 0 {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to seek to offset %d: %s\n", offset, err)
+			os.Exit(1)
+		}
+	}
+
+	var err2 error
+	if length > 0 {
+		_, err2 = io.CopyN(os.Stdout, r, length)
+	} else {
+		_, err2 = io.Copy(os.Stdout, r)
+	}
+	if err2 != nil && err2 != io.EOF {
+		fmt.Fprintf(os.Stderr, "Error: range read failed: %s\n", err2)
+		os.Exit(1)
+	}
+}
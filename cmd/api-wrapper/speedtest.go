@@ -3,28 +3,36 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type SpeedTestResult struct {
-	Type          string        `json:"type"`
-	DownloadSpeed float64       `json:"download_speed,omitempty"` // bytes per second
-	UploadSpeed   float64       `json:"upload_speed,omitempty"`   // bytes per second
+	Type          string         `json:"type"`
+	DownloadSpeed float64        `json:"download_speed,omitempty"` // bytes per second
+	UploadSpeed   float64        `json:"upload_speed,omitempty"`   // bytes per second
 	Latency       *LatencyResult `json:"latency,omitempty"`
-	Progress      float64       `json:"progress,omitempty"`
-	Status        string        `json:"status"`
+	Progress      float64        `json:"progress,omitempty"`
+	Status        string         `json:"status"`
 }
 
 type LatencyResult struct {
-	Average    int `json:"average"`
-	Min        int `json:"min"`
-	Max        int `json:"max"`
-	GooglePing int `json:"google_ping,omitempty"`
+	Average     int     `json:"average"`
+	Min         int     `json:"min"`
+	Max         int     `json:"max"`
+	Jitter      int     `json:"jitter"`
+	LossPercent float64 `json:"loss_percent"`
+	Samples     []int   `json:"samples,omitempty"`
+	GooglePing  int     `json:"google_ping,omitempty"`
 }
 
 // IrisResult represents the JSON output from Iris
@@ -60,10 +68,14 @@ func runSpeedTestWithType(testType string) {
 	cmd := exec.Command(irisPath, "--json", "--quiet")
 	cmd.Stderr = os.Stderr
 
-	// Start progress simulation in a goroutine
+	// Start progress simulation in a goroutine. It reports interim "testing"
+	// ticks off a live countingReader and, once stopped, hands back the
+	// final measured rates so the "completed" results below can use them
+	// too instead of re-deriving a speed from Iris's own numbers.
 	progressDone := make(chan bool)
+	measuredCh := make(chan liveMeasurement, 1)
 	go func() {
-		simulateProgressForTestType(testType, progressDone)
+		measuredCh <- simulateProgressForTestType(testType, progressDone)
 	}()
 
 	// Start the command
@@ -74,6 +86,7 @@ func runSpeedTestWithType(testType string) {
 	// Stop progress simulation
 	close(progressDone)
 	time.Sleep(100 * time.Millisecond) // Give progress goroutine time to stop
+	measured := <-measuredCh
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running Iris: %v\n", err)
@@ -106,11 +119,11 @@ func runSpeedTestWithType(testType string) {
 	case "latency":
 		reportLatencyFromIris(irisResult, elapsed)
 	case "download":
-		reportDownloadFromIris(irisResult, elapsed)
+		reportDownloadFromIris(irisResult, elapsed, measured.downloadBps)
 	case "upload":
-		reportUploadFromIris(irisResult, elapsed)
+		reportUploadFromIris(irisResult, elapsed, measured.uploadBps)
 	case "full":
-		reportFullFromIris(irisResult, elapsed)
+		reportFullFromIris(irisResult, elapsed, measured)
 	default:
 		fmt.Fprintf(os.Stderr, "Error: invalid test type: %s\n", testType)
 		os.Exit(1)
@@ -118,25 +131,132 @@ func runSpeedTestWithType(testType string) {
 }
 
 func reportLatencyFromIris(iris IrisResult, elapsed time.Duration) {
-	// Convert ping_ms to latency result
-	pingMs := int(iris.PingMs)
 	result := SpeedTestResult{
-		Type:   "latency",
-		Status: "completed",
-		Latency: &LatencyResult{
-			Average:    pingMs,
-			Min:        pingMs, // Iris only provides average ping
-			Max:        pingMs,
-			GooglePing: pingMs, // Use same value for Google ping
-		},
+		Type:     "latency",
+		Status:   "completed",
+		Latency:  buildLatencyResult(iris),
 		Progress: 33.0,
 	}
 	reportSpeedTestResult(result)
 }
 
-func reportDownloadFromIris(iris IrisResult, elapsed time.Duration) {
-	// Convert MB/s to bytes/s, then divide by 10
-	downloadBytesPerSec := (iris.DownloadMbps * 1024 * 1024) / 10
+// buildLatencyResult runs a native RTT probe against the server Iris tested
+// against (N TCP-connect handshakes, default 10) instead of collapsing
+// Min/Max/GooglePing to Iris's single average, and probes a known anchor
+// (8.8.8.8:53) in parallel to populate GooglePing independently.
+func buildLatencyResult(iris IrisResult) *LatencyResult {
+	const probes = 10
+
+	target := iris.Server
+	if target == "" {
+		target = "1.1.1.1:443"
+	} else if !strings.Contains(target, ":") {
+		target = target + ":443"
+	}
+
+	googlePingCh := make(chan int, 1)
+	go func() {
+		avg, _, _, _, _ := probeLatency("8.8.8.8:53", probes)
+		googlePingCh <- avg
+	}()
+
+	avg, min, max, jitter, loss := probeLatency(target, probes)
+	samples, _ := probeRTTSamples(target, probes)
+	googlePing := <-googlePingCh
+
+	if avg == 0 && iris.PingMs > 0 {
+		// Native probe couldn't reach the server (e.g. it doesn't accept
+		// raw TCP connects) - fall back to Iris's own measurement.
+		pingMs := int(iris.PingMs)
+		return &LatencyResult{Average: pingMs, Min: pingMs, Max: pingMs, GooglePing: pingMs}
+	}
+
+	return &LatencyResult{
+		Average:     avg,
+		Min:         min,
+		Max:         max,
+		Jitter:      jitter,
+		LossPercent: loss,
+		Samples:     samples,
+		GooglePing:  googlePing,
+	}
+}
+
+// probeRTTSamples performs `attempts` TCP-connect handshakes against addr,
+// recording each RTT in milliseconds, or -1 for a failed attempt.
+func probeRTTSamples(addr string, attempts int) ([]int, int) {
+	samples := make([]int, 0, attempts)
+	success := 0
+	for i := 0; i < attempts; i++ {
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+		if err != nil {
+			samples = append(samples, -1)
+			continue
+		}
+		samples = append(samples, int(time.Since(start).Milliseconds()))
+		conn.Close()
+		success++
+	}
+	return samples, success
+}
+
+// probeLatency returns average/min/max RTT, standard-deviation-based jitter,
+// and loss percentage ((attempts-successes)/attempts) from N probes to addr.
+func probeLatency(addr string, attempts int) (avg, min, max, jitter int, lossPercent float64) {
+	samples, success := probeRTTSamples(addr, attempts)
+	lossPercent = float64(attempts-success) / float64(attempts) * 100
+
+	var successful []int
+	for _, s := range samples {
+		if s >= 0 {
+			successful = append(successful, s)
+		}
+	}
+	if len(successful) == 0 {
+		return
+	}
+
+	min, max = successful[0], successful[0]
+	sum := 0
+	for _, s := range successful {
+		if s < min {
+			min = s
+		}
+		if s > max {
+			max = s
+		}
+		sum += s
+	}
+	avg = sum / len(successful)
+
+	var variance float64
+	for _, s := range successful {
+		d := float64(s - avg)
+		variance += d * d
+	}
+	variance /= float64(len(successful))
+	jitter = int(math.Sqrt(variance))
+
+	return
+}
+
+// fallbackDownloadBps and fallbackUploadBps convert Iris's own Mbps figure
+// to bytes/sec, for the rare case the live countingReader never got a
+// measurement (e.g. the warm-up request itself failed to connect).
+func fallbackDownloadBps(iris IrisResult) float64 {
+	return iris.DownloadMbps * 1024 * 1024 / 8
+}
+
+func fallbackUploadBps(iris IrisResult) float64 {
+	return iris.UploadMbps * 1024 * 1024 / 8
+}
+
+func reportDownloadFromIris(iris IrisResult, elapsed time.Duration, measuredBps float64) {
+	downloadBytesPerSec := measuredBps
+	if downloadBytesPerSec <= 0 {
+		downloadBytesPerSec = fallbackDownloadBps(iris)
+	}
 
 	result := SpeedTestResult{
 		Type:          "download",
@@ -147,9 +267,11 @@ func reportDownloadFromIris(iris IrisResult, elapsed time.Duration) {
 	reportSpeedTestResult(result)
 }
 
-func reportUploadFromIris(iris IrisResult, elapsed time.Duration) {
-	// Convert MB/s to bytes/s, then divide by 10
-	uploadBytesPerSec := (iris.UploadMbps * 1024 * 1024) / 10
+func reportUploadFromIris(iris IrisResult, elapsed time.Duration, measuredBps float64) {
+	uploadBytesPerSec := measuredBps
+	if uploadBytesPerSec <= 0 {
+		uploadBytesPerSec = fallbackUploadBps(iris)
+	}
 
 	result := SpeedTestResult{
 		Type:        "upload",
@@ -160,24 +282,21 @@ func reportUploadFromIris(iris IrisResult, elapsed time.Duration) {
 	reportSpeedTestResult(result)
 }
 
-func reportFullFromIris(iris IrisResult, elapsed time.Duration) {
+func reportFullFromIris(iris IrisResult, elapsed time.Duration, measured liveMeasurement) {
 	// Report latency
-	pingMs := int(iris.PingMs)
 	latencyResult := SpeedTestResult{
-		Type:   "latency",
-		Status: "completed",
-		Latency: &LatencyResult{
-			Average:    pingMs,
-			Min:        pingMs,
-			Max:        pingMs,
-			GooglePing: pingMs,
-		},
+		Type:     "latency",
+		Status:   "completed",
+		Latency:  buildLatencyResult(iris),
 		Progress: 33.0,
 	}
 	reportSpeedTestResult(latencyResult)
 
 	// Report download
-	downloadBytesPerSec := (iris.DownloadMbps * 1024 * 1024) / 10
+	downloadBytesPerSec := measured.downloadBps
+	if downloadBytesPerSec <= 0 {
+		downloadBytesPerSec = fallbackDownloadBps(iris)
+	}
 	downloadResult := SpeedTestResult{
 		Type:          "download",
 		Status:        "completed",
@@ -187,7 +306,10 @@ func reportFullFromIris(iris IrisResult, elapsed time.Duration) {
 	reportSpeedTestResult(downloadResult)
 
 	// Report upload
-	uploadBytesPerSec := (iris.UploadMbps * 1024 * 1024) / 10
+	uploadBytesPerSec := measured.uploadBps
+	if uploadBytesPerSec <= 0 {
+		uploadBytesPerSec = fallbackUploadBps(iris)
+	}
 	uploadResult := SpeedTestResult{
 		Type:        "upload",
 		Status:      "completed",
@@ -197,121 +319,222 @@ func reportFullFromIris(iris IrisResult, elapsed time.Duration) {
 	reportSpeedTestResult(uploadResult)
 }
 
-// simulateProgressForTestType sends progress updates during the test based on test type
-func simulateProgressForTestType(testType string, done chan bool) {
-	var startProgress, endProgress float64
-	var estimatedDuration time.Duration
+// countingReader wraps an io.Reader and tracks how many bytes have passed
+// through it so progress can be derived from real transferred bytes instead
+// of a wall-clock estimate.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		atomic.AddInt64(&c.n, int64(n))
+	}
+	return n, err
+}
+
+func (c *countingReader) Count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// liveProgressPayloadBytes is the size of the warm-up payload fetched/pushed
+// while sampling real throughput for the download/upload phases.
+const liveProgressPayloadBytes = 25 * 1024 * 1024
+
+// liveMeasurement carries the final bytes/sec rate each live-sampled phase
+// measured, so the "completed" result can reuse it instead of re-deriving a
+// speed from Iris's own numbers.
+type liveMeasurement struct {
+	downloadBps float64
+	uploadBps   float64
+}
 
+// simulateProgressForTestType drives progress updates from real, measured
+// byte counters (an anacrolix/torrent-style "bar" sampling loop) rather than
+// a fixed wall-clock ramp, and returns the final measured rate(s). For
+// "full" it runs all three phases in order.
+func simulateProgressForTestType(testType string, done chan bool) liveMeasurement {
 	switch testType {
 	case "latency":
-		startProgress = 0
-		endProgress = 33.0
-		estimatedDuration = 5 * time.Second
+		runLatencyPhase(0, 33.0, done)
+		return liveMeasurement{}
 	case "download":
-		startProgress = 33.0
-		endProgress = 66.0
-		estimatedDuration = 15 * time.Second
+		return liveMeasurement{downloadBps: runDownloadPhase(33.0, 66.0, done)}
 	case "upload":
-		startProgress = 66.0
-		endProgress = 100.0
-		estimatedDuration = 15 * time.Second
+		return liveMeasurement{uploadBps: runUploadPhase(66.0, 100.0, done)}
 	case "full":
-		// For full test, simulate all phases
-		simulateFullTestProgress(done)
-		return
-	default:
-		return
+		return simulateFullTestProgress(done)
 	}
+	return liveMeasurement{}
+}
 
-	updateInterval := 200 * time.Millisecond
-	progressRange := endProgress - startProgress
-	steps := int(estimatedDuration / updateInterval)
-	if steps < 1 {
-		steps = 1
-	}
-	progressIncrement := progressRange / float64(steps)
-
-	currentProgress := startProgress
-	startTime := time.Now()
+// simulateFullTestProgress runs the three phases back to back, each reporting
+// progress from its own live byte counter, and returns what each measured.
+func simulateFullTestProgress(done chan bool) liveMeasurement {
+	runLatencyPhase(0, 33.0, done)
+	downloadBps := runDownloadPhase(33.0, 66.0, done)
+	uploadBps := runUploadPhase(66.0, 100.0, done)
+	return liveMeasurement{downloadBps: downloadBps, uploadBps: uploadBps}
+}
 
-	for {
+// runLatencyPhase reports progress as attempts completed out of the total
+// probe count, rather than a time-based ramp.
+func runLatencyPhase(startProgress, endProgress float64, done chan bool) {
+	const samples = 10
+	for i := 0; i < samples; i++ {
 		select {
 		case <-done:
 			return
 		default:
-			elapsed := time.Since(startTime)
-			if elapsed >= estimatedDuration {
-				return
-			}
-
-			result := SpeedTestResult{
-				Type:     testType,
-				Status:   "testing",
-				Progress: currentProgress,
-			}
-			reportSpeedTestResult(result)
-
-			currentProgress += progressIncrement
-			if currentProgress > endProgress {
-				currentProgress = endProgress
-			}
-
-			time.Sleep(updateInterval)
 		}
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", "8.8.8.8:53", 2*time.Second)
+		if err == nil {
+			conn.Close()
+		}
+		_ = time.Since(start)
+
+		progress := startProgress + (endProgress-startProgress)*float64(i+1)/float64(samples)
+		reportSpeedTestResult(SpeedTestResult{
+			Type:     "latency",
+			Status:   "testing",
+			Progress: progress,
+		})
 	}
 }
 
-// simulateFullTestProgress simulates progress for a full test (latency + download + upload)
-func simulateFullTestProgress(done chan bool) {
-	// Phase 1: Latency (0-33%)
-	simulatePhase("latency", 0, 33.0, 5*time.Second, done)
-	
-	// Phase 2: Download (33-66%)
-	simulatePhase("download", 33.0, 66.0, 15*time.Second, done)
-	
-	// Phase 3: Upload (66-100%)
-	simulatePhase("upload", 66.0, 100.0, 15*time.Second, done)
+// runDownloadPhase fetches a known-size payload through a countingReader and
+// ticks every 3s, computing the instantaneous rate from the delta between
+// snapshots (mirroring the anacrolix/torrent torrentBar sampling pattern).
+// It returns the overall average rate (total bytes over total elapsed time)
+// once the transfer finishes or is cancelled, for use as the test's final
+// "completed" DownloadSpeed.
+func runDownloadPhase(startProgress, endProgress float64, done chan bool) float64 {
+	resp, err := http.Get(fmt.Sprintf("https://speed.cloudflare.com/__down?bytes=%d", liveProgressPayloadBytes))
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+
+	total := resp.ContentLength
+	if total <= 0 {
+		total = liveProgressPayloadBytes
+	}
+
+	cr := &countingReader{r: resp.Body}
+	copyDone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, cr)
+		close(copyDone)
+	}()
+
+	return sampleLiveTransfer(cr, total, startProgress, endProgress, func(rate float64, progress float64) {
+		reportSpeedTestResult(SpeedTestResult{
+			Type:          "download",
+			Status:        "testing",
+			DownloadSpeed: rate,
+			Progress:      progress,
+		})
+	}, copyDone, done)
 }
 
-func simulatePhase(testType string, startProgress, endProgress float64, duration time.Duration, done chan bool) {
-	updateInterval := 200 * time.Millisecond
-	progressRange := endProgress - startProgress
-	steps := int(duration / updateInterval)
-	if steps < 1 {
-		steps = 1
+// runUploadPhase pushes a known-size payload through a countingReader acting
+// as the request body, sampling real upload throughput the same way, and
+// returns the overall average rate once the transfer finishes or is
+// cancelled.
+func runUploadPhase(startProgress, endProgress float64, done chan bool) float64 {
+	payload := io.LimitReader(neverEndingZeroes{}, liveProgressPayloadBytes)
+	cr := &countingReader{r: payload}
+
+	req, err := http.NewRequest("POST", "https://speed.cloudflare.com/__up", cr)
+	if err != nil {
+		return 0
 	}
-	progressIncrement := progressRange / float64(steps)
+	req.ContentLength = liveProgressPayloadBytes
 
-	currentProgress := startProgress
-	startTime := time.Now()
+	reqDone := make(chan struct{})
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		close(reqDone)
+	}()
+
+	return sampleLiveTransfer(cr, liveProgressPayloadBytes, startProgress, endProgress, func(rate float64, progress float64) {
+		reportSpeedTestResult(SpeedTestResult{
+			Type:        "upload",
+			Status:      "testing",
+			UploadSpeed: rate,
+			Progress:    progress,
+		})
+	}, reqDone, done)
+}
+
+// sampleLiveTransfer ticks every 3s, computing an instantaneous byte-rate from
+// the delta between consecutive snapshots of cr, and reports progress as
+// bytes transferred over total rather than dividing a duration into steps.
+// It returns cr's total count divided by the total time sampleLiveTransfer
+// ran, as the phase's overall average rate.
+func sampleLiveTransfer(cr *countingReader, total int64, startProgress, endProgress float64, report func(rate, progress float64), transferDone chan struct{}, cancelled chan bool) float64 {
+	start := time.Now()
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	lastCount := int64(0)
+	lastTime := start
 
 	for {
 		select {
-		case <-done:
-			return
-		default:
-			elapsed := time.Since(startTime)
-			if elapsed >= duration {
-				return
+		case <-cancelled:
+			return averageRate(cr.Count(), start)
+		case <-transferDone:
+			return averageRate(cr.Count(), start)
+		case now := <-ticker.C:
+			current := cr.Count()
+			elapsed := now.Sub(lastTime).Seconds()
+			rate := float64(0)
+			if elapsed > 0 {
+				rate = float64(current-lastCount) / elapsed
 			}
+			lastCount = current
+			lastTime = now
 
-			result := SpeedTestResult{
-				Type:     testType,
-				Status:   "testing",
-				Progress: currentProgress,
+			progress := startProgress
+			if total > 0 {
+				progress = startProgress + (endProgress-startProgress)*float64(current)/float64(total)
 			}
-			reportSpeedTestResult(result)
-
-			currentProgress += progressIncrement
-			if currentProgress > endProgress {
-				currentProgress = endProgress
+			if progress > endProgress {
+				progress = endProgress
 			}
-
-			time.Sleep(updateInterval)
+			report(rate, progress)
 		}
 	}
 }
 
+// averageRate is count bytes divided by the time elapsed since start, i.e.
+// the overall average throughput of a finished or cancelled live transfer.
+func averageRate(count int64, start time.Time) float64 {
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / elapsed
+}
+
+// neverEndingZeroes is an io.Reader that fills p with zeroes indefinitely,
+// used as a stand-in payload for the upload phase's counting reader.
+type neverEndingZeroes struct{}
+
+func (neverEndingZeroes) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
 // findIrisBinary searches for the Iris binary in bundled location first, then common locations
 func findIrisBinary() (string, error) {
 	var paths []string
@@ -320,14 +543,14 @@ func findIrisBinary() (string, error) {
 	// This works for both dev and packaged apps
 	if execPath, err := os.Executable(); err == nil {
 		execDir := filepath.Dir(execPath)
-		
+
 		// In packaged apps, binaries are in Resources/bin/
 		// Try relative to executable first (for dev builds)
 		bundledPaths := []string{
-			filepath.Join(execDir, "iris"),                    // Same dir as executable
-			filepath.Join(execDir, "bin", "iris"),              // bin subdirectory
-			filepath.Join(execDir, "..", "bin", "iris"),        // Parent/bin
-			filepath.Join(execDir, "..", "Resources", "bin", "iris"), // macOS app bundle Resources/bin
+			filepath.Join(execDir, "iris"),                                 // Same dir as executable
+			filepath.Join(execDir, "bin", "iris"),                          // bin subdirectory
+			filepath.Join(execDir, "..", "bin", "iris"),                    // Parent/bin
+			filepath.Join(execDir, "..", "Resources", "bin", "iris"),       // macOS app bundle Resources/bin
 			filepath.Join(execDir, "..", "..", "Resources", "bin", "iris"), // macOS app bundle (if executable is in MacOS/)
 		}
 		paths = append(paths, bundledPaths...)
@@ -363,7 +586,7 @@ func findIrisBinary() (string, error) {
 		if err != nil {
 			continue
 		}
-		
+
 		if info, err := os.Stat(absPath); err == nil {
 			// Verify it's executable
 			if info.Mode().Perm()&0111 != 0 {
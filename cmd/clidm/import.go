@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/accelara/clidm/internal/downloader"
+)
+
+// runImport parses resumePath via downloader.Import and resumes each torrent
+// it describes, seeding piece-completion from the sidecar Import wrote so
+// the native engine doesn't re-hash data the original client already
+// verified. The info-hash-only magnet built below still has no trackers of
+// its own, but each Options' Trackers (if the resume file had any) reaches
+// the swarm anyway since TorrentDownloader adds them to the client directly.
+// outPath, if set, overrides each Options.DownloadID's save path.
+func runImport(resumePath, format, outPath string, quiet bool) {
+	opts, err := downloader.Import(resumePath, downloader.ImportFormat(format))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	for _, o := range opts {
+		infoHash := filepath.Base(o.DownloadID)
+		magnet := "magnet:?xt=urn:btih:" + infoHash
+
+		dataDir := outPath
+		if dataDir == "" {
+			dataDir = filepath.Dir(o.DownloadID)
+		}
+		if err := os.MkdirAll(dataDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", infoHash, err)
+			cancel()
+			os.Exit(1)
+		}
+
+		// Import writes the piece-bitmap sidecar next to the resume file, but
+		// TorrentDownloader reads it from its data directory.
+		sidecar := infoHash + ".piece-bitmap"
+		if bitmap, err := os.ReadFile(filepath.Join(filepath.Dir(resumePath), sidecar)); err == nil {
+			if err := os.WriteFile(filepath.Join(dataDir, sidecar), bitmap, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", infoHash, err)
+			}
+		}
+
+		o.Context = ctx
+		o.Quiet = quiet
+		if !quiet {
+			o.StatusReporter = downloader.NewTerminalBar(os.Stdout)
+		}
+
+		dl := downloader.NewTorrentDownloader(magnet, dataDir, o)
+		if err := dl.Download(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", infoHash, err)
+			cancel()
+			os.Exit(1)
+		}
+	}
+
+	cancel()
+}
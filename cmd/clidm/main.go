@@ -1,43 +1,100 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/accelara/clidm/internal/downloader"
 	"github.com/accelara/clidm/internal/utils"
 )
 
+// sourceList accumulates every -source flag occurrence, since flag's
+// built-in Value types only keep the last one for a repeated flag.
+type sourceList []string
+
+func (s *sourceList) String() string { return strings.Join(*s, ",") }
+func (s *sourceList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 func main() {
+	var sources sourceList
+	flag.Var(&sources, "source", "URL, magnet link, or .torrent file; repeat for multiple sources")
+
+	var btWebseeds sourceList
+	flag.Var(&btWebseeds, "bt-webseed", "Additional BEP 19 webseed URL to use as an HTTP fallback; repeat for multiple (combines with -webseeds)")
+
 	var (
-		source         = flag.String("source", "", "URL, magnet link, or .torrent file (required)")
-		output         = flag.String("output", "", "Output file or directory")
-		connections    = flag.Int("connections", 8, "Number of concurrent connections")
-		chunkSize      = flag.String("chunk-size", "4MB", "Chunk size for segmented downloads")
-		limit          = flag.String("limit", "", "Download rate limit")
-		proxy          = flag.String("proxy", "", "HTTP/HTTPS proxy URL")
-		retries        = flag.Int("retries", 5, "Number of retry attempts")
-		connectTimeout = flag.Int("connect-timeout", 15, "Connection timeout in seconds")
-		readTimeout    = flag.Int("read-timeout", 60, "Read timeout in seconds")
-		sha256         = flag.String("sha256", "", "SHA256 hash for file verification")
-		btUploadLimit  = flag.String("bt-upload-limit", "", "Upload rate limit for BitTorrent")
-		btSequential   = flag.Bool("bt-sequential", false, "Download files sequentially")
-		quiet          = flag.Bool("quiet", false, "Suppress progress output")
+		output             = flag.String("output", "", "Output file or directory")
+		connections        = flag.Int("connections", 8, "Number of concurrent connections")
+		chunkSize          = flag.String("chunk-size", "4MB", "Chunk size for segmented downloads")
+		limit              = flag.String("limit", "", "Download rate limit; shared across every job when more than one source is given")
+		proxy              = flag.String("proxy", "", "HTTP/HTTPS proxy URL")
+		retries            = flag.Int("retries", 5, "Number of retry attempts")
+		connectTimeout     = flag.Int("connect-timeout", 15, "Connection timeout in seconds")
+		readTimeout        = flag.Int("read-timeout", 60, "Read timeout in seconds")
+		sha256             = flag.String("sha256", "", "SHA256 hash for file verification")
+		btUploadLimit      = flag.String("bt-upload-limit", "", "Upload rate limit for BitTorrent; shared across every job when more than one source is given")
+		btSequential       = flag.Bool("bt-sequential", false, "Download files sequentially")
+		webSeeds           = flag.String("webseeds", "", "Comma-separated BEP 19 webseed URLs to use as an HTTP fallback")
+		singleFileMode     = flag.Bool("single-file-mode", false, "Write chunks directly into the output file instead of merging temp parts")
+		mirrors            = flag.String("mirrors", "", "Comma-separated equivalent URLs to dispatch chunks across via HRW hashing")
+		btBlocklist        = flag.String("bt-blocklist", "", "Path or URL to an IP blocklist (PeerGuardian/eMule .p2p format, or plain CIDR list)")
+		btBlocklistRefresh = flag.Duration("bt-blocklist-refresh", 0, "Re-fetch interval for a URL bt-blocklist (0 disables periodic refresh)")
+		btDisableUTP       = flag.Bool("bt-disable-utp", false, "Disable uTP peer connections")
+		btDisableTCP       = flag.Bool("bt-disable-tcp", false, "Disable TCP peer connections")
+		btDisableIPv6      = flag.Bool("bt-disable-ipv6", false, "Disable IPv6 peer connections")
+		btStream           = flag.Bool("bt-stream", false, "Stream a torrent file, prioritizing pieces near the read head instead of downloading everything sequentially")
+		btStreamFile       = flag.String("bt-stream-file", "", "Path (or suffix) of the file to stream in a multi-file torrent; defaults to the largest file")
+		btReadahead        = flag.String("bt-readahead", "", "Bytes ahead of the read head to prioritize while streaming (defaults to 4 pieces)")
+		stream             = flag.Bool("stream", false, "Alias for --bt-stream; set whichever one you like")
+		streamFile         = flag.String("stream-file", "", "Alias for --bt-stream-file; set whichever one you like")
+		readahead          = flag.String("readahead", "", "Alias for --bt-readahead; set whichever one you like")
+		streamOnly         = flag.Bool("stream-only", false, "With --bt-stream, skip pieces outside the read/readahead window entirely")
+		btStorage          = flag.String("bt-storage", "", "Torrent storage backend: file (default), mmap, piece-file, piece, or memory")
+		btEmitPieceBitmap  = flag.Bool("bt-emit-piece-bitmap", false, "Include a base64 per-piece completion bitmap in status reports")
+		btSelect           = flag.String("bt-select", "", "Comma-separated glob patterns to select files in a multi-file torrent, e.g. \"*.mkv,!sample/*\"")
+		btSelectIndex      = flag.String("bt-select-index", "", "Comma-separated 0-based file indices to select in a multi-file torrent, e.g. \"0,3,5\"")
+		metricsAddr        = flag.String("metrics-addr", "", "Address to serve /debug/vars and /metrics on, e.g. \":9090\" (disabled by default)")
+		statusAddr         = flag.String("status-addr", "", "Alias for --metrics-addr; set whichever one you like")
+		quiet              = flag.Bool("quiet", false, "Suppress progress output")
+		batch              = flag.String("batch", "", "File listing one source per line (\"<source>\", \"<source> <outPath>\", or \"<source> <outPath> <sha256>\") to run as a batch alongside -source")
+		parallel           = flag.Int("parallel", 4, "Max jobs to run at once when more than one source is given")
+		failFast           = flag.Bool("fail-fast", false, "With multiple sources, cancel every job as soon as one fails instead of letting the rest finish")
+		ariaRPC            = flag.String("aria-rpc", "", "Delegate to an already-running aria2c daemon over this JSON-RPC endpoint instead of accelara's own engine, e.g. \"http://localhost:6800/jsonrpc\"")
+		ariaSecret         = flag.String("aria-secret", "", "aria2 RPC secret token, if the daemon requires one")
+		qbitURL            = flag.String("qbit-url", "", "Delegate to an already-running qBittorrent instance's Web API at this base URL instead of accelara's own engine")
+		qbitUsername       = flag.String("qbit-username", "", "qBittorrent Web API username")
+		qbitPassword       = flag.String("qbit-password", "", "qBittorrent Web API password")
+		importResume       = flag.String("import", "", "Path to a qBittorrent/Transmission/libtorrent resume file to seed a resumed download from")
+		importFormat       = flag.String("import-format", "qbittorrent-fastresume", "Format of -import: qbittorrent-fastresume, transmission-resume, or libtorrent-resume")
 	)
 
 	flag.Parse()
 
-	if *source == "" {
-		if len(flag.Args()) > 0 {
-			*source = flag.Args()[0]
-		} else {
-			fmt.Fprintf(os.Stderr, "Error: source is required\n")
-			os.Exit(1)
-		}
+	if *importResume != "" {
+		runImport(*importResume, *importFormat, *output, *quiet)
+		return
+	}
+
+	jobs, err := resolveJobs([]string(sources), flag.Args(), *batch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(jobs) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: source is required\n")
+		os.Exit(1)
 	}
 
 	outPath := *output
@@ -45,12 +102,26 @@ func main() {
 		outPath = "."
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
+	statusServerAddr := *metricsAddr
+	if statusServerAddr == "" {
+		statusServerAddr = *statusAddr
+	}
+	metricsServer, err := downloader.StartMetricsServer(statusServerAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting metrics server: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Handle CTRL+C
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
 		fmt.Println("\nInterrupted. Resume supported.")
+		cancel()
+		downloader.ShutdownMetricsServer(metricsServer)
 		os.Exit(0)
 	}()
 
@@ -78,42 +149,282 @@ func main() {
 		}
 	}
 
-	absOutPath, err := filepath.Abs(outPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error resolving output path: %v\n", err)
-		os.Exit(1)
+	absOutPath := outPath
+	if outPath != "-" {
+		absOutPath, err = filepath.Abs(outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving output path: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	opts := downloader.Options{
-		Connections:    *connections,
-		ChunkSize:      chunkSizeBytes,
-		RateLimit:      limitBytes,
-		Proxy:          *proxy,
-		Retries:        *retries,
-		ConnectTimeout: *connectTimeout,
-		ReadTimeout:    *readTimeout,
-		SHA256:         *sha256,
-		BTUploadLimit:  btUploadLimitBytes,
-		BTSequential:   *btSequential,
-		Quiet:          *quiet,
-	}
-
-	if utils.IsTorrentLike(*source) {
-		dl := downloader.NewTorrentDownloader(*source, absOutPath, opts)
-		if err := dl.Download(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	var webSeedList []string
+	if *webSeeds != "" {
+		webSeedList = strings.Split(*webSeeds, ",")
+	}
+	webSeedList = append(webSeedList, btWebseeds...)
+
+	var mirrorList []string
+	if *mirrors != "" {
+		mirrorList = strings.Split(*mirrors, ",")
+	}
+
+	readaheadStr := *btReadahead
+	if readaheadStr == "" {
+		readaheadStr = *readahead
+	}
+	var readaheadBytes int64
+	if readaheadStr != "" {
+		readaheadBytes, err = utils.ParseBytes(readaheadStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing bt-readahead: %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		outFile := absOutPath
-		if info, err := os.Stat(absOutPath); err == nil && info.IsDir() {
-			outFile = filepath.Join(absOutPath, "download.tmp")
+	}
+
+	var fileSelect *downloader.FileSelector
+	if *btSelect != "" {
+		fileSelect = &downloader.FileSelector{Globs: strings.Split(*btSelect, ",")}
+	} else if *btSelectIndex != "" {
+		var indices []int
+		for _, s := range strings.Split(*btSelectIndex, ",") {
+			idx, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing bt-select-index: %v\n", err)
+				os.Exit(1)
+			}
+			indices = append(indices, idx)
+		}
+		fileSelect = &downloader.FileSelector{Indices: indices}
+	}
+
+	var reporter downloader.StatusReporter
+	if !*quiet && len(jobs) == 1 {
+		reporter = downloader.NewTerminalBar(os.Stdout)
+	}
+
+	opts := downloader.Options{
+		Connections:        *connections,
+		ChunkSize:          chunkSizeBytes,
+		RateLimit:          limitBytes,
+		Proxy:              *proxy,
+		Retries:            *retries,
+		ConnectTimeout:     *connectTimeout,
+		ReadTimeout:        *readTimeout,
+		SHA256:             *sha256,
+		BTUploadLimit:      btUploadLimitBytes,
+		BTSequential:       *btSequential,
+		WebSeeds:           webSeedList,
+		SingleFileMode:     *singleFileMode,
+		Mirrors:            mirrorList,
+		BTBlocklist:        *btBlocklist,
+		BTBlocklistRefresh: *btBlocklistRefresh,
+		BTDisableUTP:       *btDisableUTP,
+		BTDisableTCP:       *btDisableTCP,
+		BTDisableIPv6:      *btDisableIPv6,
+		BTStream:           *btStream || *stream,
+		BTStreamFile:       firstNonEmpty(*btStreamFile, *streamFile),
+		BTReadahead:        readaheadBytes,
+		BTStreamOnly:       *streamOnly,
+		BTStorage:          *btStorage,
+		BTEmitPieceBitmap:  *btEmitPieceBitmap,
+		BTFileSelect:       fileSelect,
+		MetricsAddr:        statusServerAddr,
+		StatusReporter:     reporter,
+		Quiet:              *quiet,
+		Context:            ctx,
+		AriaRPC:            *ariaRPC,
+		AriaSecret:         *ariaSecret,
+		QbitURL:            *qbitURL,
+		QbitUsername:       *qbitUsername,
+		QbitPassword:       *qbitPassword,
+	}
+
+	if len(jobs) == 1 {
+		job := jobs[0]
+		source := job.SourceURL
+		sha256Value := *sha256
+		if job.SHA256 != "" {
+			sha256Value = job.SHA256
+		}
+		opts.SHA256 = sha256Value
+
+		jobOutPath := absOutPath
+		if job.OutPath != "" {
+			if jobOutPath, err = filepath.Abs(job.OutPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving output path: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if opts.AriaRPC != "" || opts.QbitURL != "" {
+			opts.DownloadID = jobOutPath
+			backend := downloader.SelectBackend(opts)
+			if err := runViaBackend(ctx, backend, source, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				cancel()
+				downloader.ShutdownMetricsServer(metricsServer)
+				os.Exit(1)
+			}
+		} else if utils.IsTorrentLike(source) {
+			dl := downloader.NewTorrentDownloader(source, jobOutPath, opts)
+			if err := dl.Download(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				cancel()
+				downloader.ShutdownMetricsServer(metricsServer)
+				os.Exit(1)
+			}
+		} else {
+			outFile := jobOutPath
+			if info, err := os.Stat(jobOutPath); err == nil && info.IsDir() {
+				outFile = filepath.Join(jobOutPath, "download.tmp")
+			}
+
+			dl := downloader.NewHTTPDownloader(source, outFile, opts)
+			if err := dl.Download(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				cancel()
+				downloader.ShutdownMetricsServer(metricsServer)
+				os.Exit(1)
+			}
 		}
 
-		dl := downloader.NewHTTPDownloader(*source, outFile, opts)
-		if err := dl.Download(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if reporter != nil {
+			fmt.Println()
+		}
+	} else {
+		if failures := runBatch(ctx, jobs, opts, absOutPath, *parallel, *failFast, limitBytes, btUploadLimitBytes, *quiet); failures > 0 {
+			cancel()
+			downloader.ShutdownMetricsServer(metricsServer)
 			os.Exit(1)
 		}
 	}
+
+	cancel()
+	downloader.ShutdownMetricsServer(metricsServer)
+}
+
+// runViaBackend starts source on backend and polls Status until it completes
+// or errors, for the -aria-rpc/-qbit-url delegation path.
+func runViaBackend(ctx context.Context, backend downloader.Backend, source string, opts downloader.Options) error {
+	handle, err := backend.Start(ctx, source, opts)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			p, err := backend.Status(handle.ID)
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(p.Status) {
+			case "completed", "complete", "seeding":
+				return nil
+			case "error":
+				return fmt.Errorf("%s", p.Message)
+			}
+		}
+	}
+}
+
+// firstNonEmpty returns a, or b if a is empty.
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
+
+// resolveJobs merges every -source occurrence, positional arguments, and (if
+// batchPath is set) the lines of a -batch file into one job list, in that
+// order. It errors only on a bad batch file; an empty result is left for the
+// caller to reject, matching the existing "source is required" check.
+func resolveJobs(sources, positional []string, batchPath string) ([]downloader.Job, error) {
+	var jobs []downloader.Job
+	for _, s := range sources {
+		jobs = append(jobs, downloader.Job{SourceURL: s})
+	}
+	for _, s := range positional {
+		jobs = append(jobs, downloader.Job{SourceURL: s})
+	}
+
+	if batchPath != "" {
+		f, err := os.Open(batchPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open batch file: %w", err)
+		}
+		defer f.Close()
+
+		batchJobs, err := downloader.ParseBatchFile(f)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, batchJobs...)
+	}
+
+	return jobs, nil
+}
+
+// runBatch resolves each job's output path, runs them all through a
+// Scheduler sharing rateLimit/uploadLimit across the whole batch, and prints
+// a result line per job followed by a summary. It returns the number of
+// jobs that failed; the caller decides what that means for the exit code.
+func runBatch(ctx context.Context, jobs []downloader.Job, baseOpts downloader.Options, baseDir string, parallel int, failFast bool, rateLimit, uploadLimit int64, quiet bool) int {
+	for i := range jobs {
+		jobs[i].OutPath = resolveJobOutPath(baseDir, jobs[i].OutPath, jobs[i].SourceURL, i)
+		jobs[i].Options = baseOpts
+	}
+
+	sched := downloader.NewScheduler(parallel, failFast)
+	sched.RateLimit = rateLimit
+	sched.BTUploadLimit = uploadLimit
+	if !quiet {
+		sched.Reporter = downloader.NewBatchLineReporter(os.Stdout)
+	}
+
+	results := sched.Run(ctx, jobs)
+
+	failures := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failures++
+			fmt.Fprintf(os.Stderr, "[FAIL] %s: %v\n", r.Job.SourceURL, r.Err)
+		} else {
+			fmt.Printf("[OK]   %s -> %s\n", r.Job.SourceURL, r.Job.OutPath)
+		}
+	}
+	fmt.Printf("\n%d/%d jobs succeeded\n", len(jobs)-failures, len(jobs))
+	return failures
+}
+
+// resolveJobOutPath picks the destination for one batch job: explicit wins
+// (resolved against baseDir if relative), a torrent source downloads into
+// baseDir itself same as the single-source path, and an HTTP(S) source
+// without an explicit path falls back to its URL's basename, or a
+// download-<idx>.tmp placeholder if the URL doesn't have one.
+func resolveJobOutPath(baseDir, explicit, source string, idx int) string {
+	if explicit != "" {
+		if filepath.IsAbs(explicit) {
+			return explicit
+		}
+		return filepath.Join(baseDir, explicit)
+	}
+	if utils.IsTorrentLike(source) {
+		return baseDir
+	}
+
+	name := ""
+	if u, err := url.Parse(source); err == nil {
+		name = filepath.Base(u.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		name = fmt.Sprintf("download-%d.tmp", idx)
+	}
+	return filepath.Join(baseDir, name)
 }
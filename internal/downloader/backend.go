@@ -0,0 +1,421 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/accelara/clidm/internal/utils"
+)
+
+// Handle identifies a download started through a Backend.
+type Handle struct {
+	ID string
+}
+
+// Progress is a backend-agnostic snapshot of a download's state.
+type Progress struct {
+	Status     string // "downloading", "paused", "completed", "error"
+	Downloaded int64
+	Total      int64
+	Message    string
+}
+
+// Backend abstracts over where a download actually runs: accelara's own
+// engine, or a daemon like aria2 or qBittorrent that the user already has
+// tuned and running. Options.Backend selects which one NewHTTPDownloader's
+// callers should delegate to.
+type Backend interface {
+	Start(ctx context.Context, sourceURL string, opts Options) (Handle, error)
+	Status(id string) (Progress, error)
+	Cancel(id string) error
+	Remove(id string) error
+}
+
+// NativeBackend runs downloads with accelara's own HTTPDownloader/TorrentDownloader,
+// the same as calling them directly. It exists so callers can treat "no
+// external daemon configured" as just another Backend implementation.
+type NativeBackend struct {
+	mu      sync.Mutex
+	handles map[string]*nativeHandle
+	nextID  int64
+}
+
+type nativeHandle struct {
+	reporter *backendReporter
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+}
+
+// backendReporter adapts the StatusReporter callback style into the
+// poll-based Progress snapshot Backend.Status returns.
+type backendReporter struct {
+	inner  StatusReporter
+	latest atomic.Value // map[string]interface{}
+}
+
+func (r *backendReporter) Report(status map[string]interface{}) {
+	r.latest.Store(status)
+	if r.inner != nil {
+		r.inner.Report(status)
+	}
+}
+
+func NewNativeBackend() *NativeBackend {
+	return &NativeBackend{handles: make(map[string]*nativeHandle)}
+}
+
+// SelectBackend resolves which Backend a caller should use: the explicitly
+// configured one, a daemon implied by AriaRPC/QbitURL, or the native engine.
+func SelectBackend(opts Options) Backend {
+	if opts.Backend != nil {
+		return opts.Backend
+	}
+	if opts.AriaRPC != "" {
+		return NewAria2Backend(opts.AriaRPC, opts.AriaSecret)
+	}
+	if opts.QbitURL != "" {
+		return NewQbitBackend(opts.QbitURL, opts.QbitUsername, opts.QbitPassword)
+	}
+	return NewNativeBackend()
+}
+
+func (b *NativeBackend) Start(ctx context.Context, sourceURL string, opts Options) (Handle, error) {
+	b.mu.Lock()
+	b.nextID++
+	id := fmt.Sprintf("native-%d", b.nextID)
+
+	reporter := &backendReporter{inner: opts.StatusReporter}
+	opts.StatusReporter = reporter
+	ctx, cancel := context.WithCancel(ctx)
+	opts.Context = ctx
+
+	h := &nativeHandle{reporter: reporter, cancel: cancel, done: make(chan struct{})}
+	b.handles[id] = h
+	b.mu.Unlock()
+
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		var outPath string
+		if opts.DownloadID != "" {
+			outPath = opts.DownloadID
+		}
+		if utils.IsTorrentLike(sourceURL) {
+			h.err = NewTorrentDownloader(sourceURL, outPath, opts).Download()
+		} else {
+			h.err = NewHTTPDownloader(sourceURL, outPath, opts).Download()
+		}
+	}()
+
+	return Handle{ID: id}, nil
+}
+
+func (b *NativeBackend) Status(id string) (Progress, error) {
+	b.mu.Lock()
+	h, ok := b.handles[id]
+	b.mu.Unlock()
+	if !ok {
+		return Progress{}, fmt.Errorf("unknown handle: %s", id)
+	}
+	select {
+	case <-h.done:
+		if h.err != nil {
+			return Progress{Status: "error", Message: h.err.Error()}, nil
+		}
+		return Progress{Status: "completed"}, nil
+	default:
+	}
+	status, _ := h.reporter.latest.Load().(map[string]interface{})
+	p := Progress{Status: "downloading"}
+	if v, ok := status["downloaded"].(int64); ok {
+		p.Downloaded = v
+	}
+	if v, ok := status["total"].(int64); ok {
+		p.Total = v
+	}
+	return p, nil
+}
+
+func (b *NativeBackend) Cancel(id string) error {
+	b.mu.Lock()
+	h, ok := b.handles[id]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown handle: %s", id)
+	}
+	// Cancels the per-download context Start derived from its caller's ctx,
+	// which HTTPDownloader/TorrentDownloader now check in their transfer
+	// loops, so this actually stops the in-flight download instead of being
+	// a no-op.
+	h.cancel()
+	return nil
+}
+
+func (b *NativeBackend) Remove(id string) error {
+	b.mu.Lock()
+	delete(b.handles, id)
+	b.mu.Unlock()
+	return nil
+}
+
+// Aria2Backend drives an already-running aria2c daemon over its JSON-RPC
+// interface (https://aria2.github.io/manual/en/html/aria2c.html#rpc-interface),
+// configured via Options.AriaRPC (e.g. "http://localhost:6800/jsonrpc").
+type Aria2Backend struct {
+	rpcURL string
+	secret string
+	client *http.Client
+}
+
+func NewAria2Backend(rpcURL, secret string) *Aria2Backend {
+	jar, _ := cookiejar.New(nil)
+	return &Aria2Backend{rpcURL: rpcURL, secret: secret, client: &http.Client{Jar: jar}}
+}
+
+func (b *Aria2Backend) call(method string, params []interface{}) (json.RawMessage, error) {
+	if b.secret != "" {
+		params = append([]interface{}{"token:" + b.secret}, params...)
+	}
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      "accelara",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.Post(b.rpcURL, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, err
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("aria2: %s", rpcResp.Error.Message)
+	}
+	return rpcResp.Result, nil
+}
+
+func (b *Aria2Backend) Start(ctx context.Context, sourceURL string, opts Options) (Handle, error) {
+	params := []interface{}{[]string{sourceURL}, map[string]interface{}{}}
+	if opts.DownloadID != "" {
+		params[1].(map[string]interface{})["out"] = opts.DownloadID
+	}
+	if opts.Connections > 0 {
+		params[1].(map[string]interface{})["split"] = strconv.Itoa(opts.Connections)
+	}
+	if opts.RateLimit > 0 {
+		params[1].(map[string]interface{})["max-download-limit"] = strconv.FormatInt(opts.RateLimit, 10)
+	}
+
+	result, err := b.call("aria2.addUri", params)
+	if err != nil {
+		return Handle{}, err
+	}
+	var gid string
+	if err := json.Unmarshal(result, &gid); err != nil {
+		return Handle{}, fmt.Errorf("aria2: unexpected addUri response: %w", err)
+	}
+	return Handle{ID: gid}, nil
+}
+
+func (b *Aria2Backend) Status(id string) (Progress, error) {
+	result, err := b.call("aria2.tellStatus", []interface{}{id})
+	if err != nil {
+		return Progress{}, err
+	}
+	var status struct {
+		Status          string `json:"status"`
+		TotalLength     string `json:"totalLength"`
+		CompletedLength string `json:"completedLength"`
+		ErrorMessage    string `json:"errorMessage"`
+	}
+	if err := json.Unmarshal(result, &status); err != nil {
+		return Progress{}, err
+	}
+	total, _ := strconv.ParseInt(status.TotalLength, 10, 64)
+	completed, _ := strconv.ParseInt(status.CompletedLength, 10, 64)
+	return Progress{
+		Status:     status.Status,
+		Downloaded: completed,
+		Total:      total,
+		Message:    status.ErrorMessage,
+	}, nil
+}
+
+func (b *Aria2Backend) Cancel(id string) error {
+	_, err := b.call("aria2.pause", []interface{}{id})
+	return err
+}
+
+func (b *Aria2Backend) Remove(id string) error {
+	_, err := b.call("aria2.removeDownloadResult", []interface{}{id})
+	return err
+}
+
+// QbitBackend drives an already-running qBittorrent instance over its Web
+// API v2 (https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)),
+// configured via Options.QbitURL, QbitUsername and QbitPassword.
+type QbitBackend struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewQbitBackend(baseURL, username, password string) *QbitBackend {
+	jar, _ := cookiejar.New(nil)
+	return &QbitBackend{baseURL: strings.TrimRight(baseURL, "/"), username: username, password: password, client: &http.Client{Jar: jar}}
+}
+
+func (b *QbitBackend) login() error {
+	form := url.Values{"username": {b.username}, "password": {b.password}}
+	resp, err := b.client.PostForm(b.baseURL+"/api/v2/auth/login", form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("qbittorrent: login failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (b *QbitBackend) Start(ctx context.Context, sourceURL string, opts Options) (Handle, error) {
+	if err := b.login(); err != nil {
+		return Handle{}, err
+	}
+
+	form := url.Values{"urls": {sourceURL}}
+	if opts.DownloadID != "" {
+		form.Set("savepath", opts.DownloadID)
+	}
+	resp, err := b.client.PostForm(b.baseURL+"/api/v2/torrents/add", form)
+	if err != nil {
+		return Handle{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Handle{}, fmt.Errorf("qbittorrent: torrents/add failed with status %d", resp.StatusCode)
+	}
+
+	// qBittorrent doesn't hand back an ID on add, so the source URL is the
+	// handle ID. Status matches it against each torrents/info entry's hash
+	// and magnet_uri only; a source that is an http(s) .torrent URL is not
+	// matched there.
+	return Handle{ID: sourceURL}, nil
+}
+
+// qbitTorrentInfo is one entry of the qBittorrent torrents/info response,
+// used by both Status and findHash to match a Handle's id (hash or the
+// source URL/magnet Start recorded it under) against a real torrent.
+type qbitTorrentInfo struct {
+	MagnetURI string  `json:"magnet_uri"`
+	Hash      string  `json:"hash"`
+	State     string  `json:"state"`
+	Size      int64   `json:"size"`
+	Progress  float64 `json:"progress"`
+}
+
+func (b *QbitBackend) torrentsInfo() ([]qbitTorrentInfo, error) {
+	resp, err := b.client.Get(b.baseURL + "/api/v2/torrents/info")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var torrents []qbitTorrentInfo
+	if err := json.Unmarshal(body, &torrents); err != nil {
+		return nil, err
+	}
+	return torrents, nil
+}
+
+func (b *QbitBackend) Status(id string) (Progress, error) {
+	torrents, err := b.torrentsInfo()
+	if err != nil {
+		return Progress{}, err
+	}
+
+	for _, t := range torrents {
+		if t.Hash == id || t.MagnetURI == id {
+			return Progress{
+				Status:     t.State,
+				Downloaded: int64(float64(t.Size) * t.Progress),
+				Total:      t.Size,
+			}, nil
+		}
+	}
+	return Progress{}, fmt.Errorf("qbittorrent: torrent %s not found", id)
+}
+
+// findHash resolves a Handle's id - which Start sets to the source URL or
+// magnet link, since qBittorrent doesn't hand back a hash on add - to the
+// info-hash the torrents/pause and torrents/delete endpoints actually expect.
+func (b *QbitBackend) findHash(id string) (string, error) {
+	torrents, err := b.torrentsInfo()
+	if err != nil {
+		return "", err
+	}
+	for _, t := range torrents {
+		if t.Hash == id || t.MagnetURI == id {
+			return t.Hash, nil
+		}
+	}
+	return "", fmt.Errorf("qbittorrent: torrent %s not found", id)
+}
+
+func (b *QbitBackend) Cancel(id string) error {
+	hash, err := b.findHash(id)
+	if err != nil {
+		return err
+	}
+	form := url.Values{"hashes": {hash}}
+	resp, err := b.client.PostForm(b.baseURL+"/api/v2/torrents/pause", form)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (b *QbitBackend) Remove(id string) error {
+	hash, err := b.findHash(id)
+	if err != nil {
+		return err
+	}
+	form := url.Values{"hashes": {hash}, "deleteFiles": {"false"}}
+	resp, err := b.client.PostForm(b.baseURL+"/api/v2/torrents/delete", form)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,79 @@
+package downloader
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// BufferPool hands out fixed-size read buffers for chunk IO, avoiding a
+// fresh GC-tracked allocation per chunk on every Read() loop. It's meant to
+// be created once and shared across every HTTPDownloader in a batch run via
+// Options.BufferPool.
+type BufferPool struct {
+	size    int
+	useMmap bool
+	pool    atomic.Value // *sync.Pool
+	stopCh  chan struct{}
+}
+
+// NewBufferPool returns a pool of size-byte buffers. When useMmap is true,
+// buffers are backed by anonymous mmap regions instead of the Go heap, so
+// they don't count against GC pressure - useful for very high concurrency
+// downloads of large files. If flushTime is non-zero, idle pooled buffers
+// are released every flushTime by swapping in a fresh underlying sync.Pool.
+func NewBufferPool(size int, useMmap bool, flushTime time.Duration) *BufferPool {
+	p := &BufferPool{size: size, useMmap: useMmap, stopCh: make(chan struct{})}
+	p.pool.Store(p.newPool())
+	if flushTime > 0 {
+		go p.flushLoop(flushTime)
+	}
+	return p
+}
+
+func (p *BufferPool) newPool() *sync.Pool {
+	size, useMmap := p.size, p.useMmap
+	return &sync.Pool{
+		New: func() interface{} {
+			if useMmap {
+				if buf, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE); err == nil {
+					return buf
+				}
+			}
+			return make([]byte, size)
+		},
+	}
+}
+
+// Get returns a size-byte buffer, reusing a pooled one if available.
+func (p *BufferPool) Get() []byte {
+	return p.pool.Load().(*sync.Pool).Get().([]byte)
+}
+
+// Put returns buf to the pool. Buffers of the wrong size (e.g. from a pool
+// reconfigured mid-run) are silently dropped rather than pooled.
+func (p *BufferPool) Put(buf []byte) {
+	if cap(buf) != p.size {
+		return
+	}
+	p.pool.Load().(*sync.Pool).Put(buf[:p.size])
+}
+
+func (p *BufferPool) flushLoop(flushTime time.Duration) {
+	ticker := time.NewTicker(flushTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.pool.Store(p.newPool())
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops the periodic flush goroutine, if one was started.
+func (p *BufferPool) Close() {
+	close(p.stopCh)
+}
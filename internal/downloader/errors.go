@@ -0,0 +1,169 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// TransientError wraps a failure that's worth retrying - a dropped
+// connection, a timeout, a 5xx/429 response. RetryAfter is non-zero when the
+// server told us how long to wait (e.g. a 429/503's Retry-After header).
+type TransientError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *TransientError) Error() string { return e.Err.Error() }
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// PermanentError wraps a failure retrying won't fix - a 404, a checksum
+// mismatch, a malformed URL.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// RangeNotSupportedError wraps a failure caused by the server not honoring
+// ranged requests, so callers can fall back to a single-stream download
+// instead of retrying the same request.
+type RangeNotSupportedError struct {
+	Err error
+}
+
+func (e *RangeNotSupportedError) Error() string { return e.Err.Error() }
+func (e *RangeNotSupportedError) Unwrap() error { return e.Err }
+
+// classifyError turns a raw error (and, if available, the HTTP response that
+// produced it) into one of TransientError, PermanentError or
+// RangeNotSupportedError, so callers can branch on retryability with
+// errors.As instead of string-matching error messages.
+func classifyError(err error, resp *http.Response) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, ErrRangeNotSupported) {
+		return &RangeNotSupportedError{Err: err}
+	}
+
+	if resp != nil {
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			// Caller asked for a range and got a full body back.
+			return &RangeNotSupportedError{Err: err}
+		case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+			return &TransientError{Err: err, RetryAfter: retryAfter(resp)}
+		case resp.StatusCode >= 400:
+			return &PermanentError{Err: err}
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &TransientError{Err: err}
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() {
+			return &TransientError{Err: err}
+		}
+		err = urlErr.Unwrap()
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return &TransientError{Err: err}
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return &TransientError{Err: err}
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ETIMEDOUT, syscall.EPIPE:
+			return &TransientError{Err: err}
+		}
+	}
+
+	return &PermanentError{Err: err}
+}
+
+// retryAfter parses a Retry-After response header, which per RFC 9110 can be
+// either a number of seconds or an HTTP-date. It returns 0 if the header is
+// absent or unparseable.
+func retryAfter(resp *http.Response) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryPolicy decides whether a failed attempt should be retried, and if so
+// after how long. Options.RetryPolicy lets callers override the default
+// exponential backoff with their own (e.g. a test fixture that never
+// sleeps).
+type RetryPolicy interface {
+	ShouldRetry(attempt int, err error) (retry bool, wait time.Duration)
+}
+
+// DefaultRetryPolicy retries transient errors with exponential backoff up to
+// MaxAttempts, honoring a server-supplied RetryAfter when present. Permanent
+// and range-not-supported errors are never retried.
+type DefaultRetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewDefaultRetryPolicy builds a DefaultRetryPolicy allowing maxAttempts, matching
+// the backoff curve handleConnectionFailure used before RetryPolicy existed
+// (1s, 2s, 4s, ... capped at 30s).
+func NewDefaultRetryPolicy(maxAttempts int) *DefaultRetryPolicy {
+	return &DefaultRetryPolicy{MaxAttempts: maxAttempts, BaseBackoff: time.Second, MaxBackoff: 30 * time.Second}
+}
+
+func (p *DefaultRetryPolicy) ShouldRetry(attempt int, err error) (bool, time.Duration) {
+	if attempt >= p.MaxAttempts {
+		return false, 0
+	}
+
+	var perm *PermanentError
+	if errors.As(err, &perm) {
+		return false, 0
+	}
+	var rangeErr *RangeNotSupportedError
+	if errors.As(err, &rangeErr) {
+		return false, 0
+	}
+
+	var trans *TransientError
+	if errors.As(err, &trans) && trans.RetryAfter > 0 {
+		return true, trans.RetryAfter
+	}
+
+	backoff := p.BaseBackoff * time.Duration(uint(1)<<uint(attempt))
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return true, backoff
+}
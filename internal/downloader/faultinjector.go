@@ -0,0 +1,105 @@
+package downloader
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+)
+
+// FaultInjector lets tests (and chaos-testing callers) exercise the
+// retry/fallback branches in downloadChunk/downloadSegmented without a real
+// flaky server. A nil Options.FaultInjector - the default - costs nothing;
+// every call site checks for nil before invoking a hook.
+type FaultInjector interface {
+	// BeforeRequest runs before the chunk's HTTP request is sent. A non-nil
+	// error is handled like a transport error from client.Do, except that any
+	// isConnectionError match (not only reset/refused/timeout) marks the
+	// connection as failed when more than one connection is in use.
+	BeforeRequest(chunkIdx int) error
+
+	// TransformResponse runs after a successful response, before
+	// downloadChunk inspects its status code. It may return resp unchanged,
+	// or a modified *http.Response (e.g. with StatusCode swapped to simulate
+	// a server that ignores Range requests).
+	TransformResponse(chunkIdx int, resp *http.Response) *http.Response
+
+	// CorruptRead runs after each successful Read into p, before the bytes
+	// are written to the part file. offset is the chunk-relative byte
+	// offset of p[0]. A non-nil error aborts the read loop exactly like a
+	// real read error would.
+	CorruptRead(chunkIdx, offset int, p []byte) error
+}
+
+// RandomFaultInjector is a built-in FaultInjector driven by per-fault-type
+// probabilities and a seed, so runs are reproducible. Each rate is the
+// chance (0.0-1.0) that the corresponding hook triggers that fault on a
+// given call; unset rates default to 0 (no fault).
+type RandomFaultInjector struct {
+	Seed int64
+
+	ConnResetRate float64 // BeforeRequest fails with a connection-reset-like error
+	TimeoutRate   float64 // BeforeRequest fails with a timeout-like error
+	ThrottleRate  float64 // TransformResponse rewrites the status to 429 or 503
+	ShortReadRate float64 // CorruptRead fails with a connection-reset-like error mid-chunk to simulate a short read
+	BitFlipRate   float64 // CorruptRead flips one bit per call, for SHA256-mismatch tests
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRandomFaultInjector builds a RandomFaultInjector seeded for
+// reproducible runs. Rates default to 0 and are set on the returned value.
+func NewRandomFaultInjector(seed int64) *RandomFaultInjector {
+	return &RandomFaultInjector{Seed: seed, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (f *RandomFaultInjector) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rng == nil {
+		f.rng = rand.New(rand.NewSource(f.Seed))
+	}
+	return f.rng.Float64() < rate
+}
+
+func (f *RandomFaultInjector) BeforeRequest(chunkIdx int) error {
+	if f.chance(f.ConnResetRate) {
+		return fmt.Errorf("chunk %d: connection reset by peer (injected)", chunkIdx)
+	}
+	if f.chance(f.TimeoutRate) {
+		return fmt.Errorf("chunk %d: i/o timeout (injected)", chunkIdx)
+	}
+	return nil
+}
+
+func (f *RandomFaultInjector) TransformResponse(chunkIdx int, resp *http.Response) *http.Response {
+	if f.chance(f.ThrottleRate) {
+		if f.chance(0.5) {
+			resp.StatusCode = http.StatusTooManyRequests
+		} else {
+			resp.StatusCode = http.StatusServiceUnavailable
+		}
+	}
+	return resp
+}
+
+func (f *RandomFaultInjector) CorruptRead(chunkIdx, offset int, p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	if f.chance(f.BitFlipRate) {
+		f.mu.Lock()
+		i := f.rng.Intn(len(p))
+		bit := byte(1) << uint(f.rng.Intn(8))
+		f.mu.Unlock()
+		p[i] ^= bit
+	}
+	if f.chance(f.ShortReadRate) {
+		return fmt.Errorf("chunk %d: connection reset by peer at offset %d (injected short read)", chunkIdx, offset)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// noRetryPolicy never retries, so handleConnectionFailure pauses (and
+// returns) on the very first failure instead of sleeping through
+// DefaultRetryPolicy's exponential backoff.
+type noRetryPolicy struct{}
+
+func (noRetryPolicy) ShouldRetry(attempt int, err error) (bool, time.Duration) { return false, 0 }
+
+// newTestDownloader builds an HTTPDownloader pointed at srv without going
+// through probe()/Download(), so tests can call downloadChunk directly with
+// hand-picked chunks and a scratch tempDir.
+func newTestDownloader(t *testing.T, srv *httptest.Server, opts Options) *HTTPDownloader {
+	t.Helper()
+	if opts.RetryPolicy == nil {
+		opts.RetryPolicy = noRetryPolicy{}
+	}
+	d := NewHTTPDownloader(srv.URL, filepath.Join(t.TempDir(), "out.bin"), opts)
+	d.tempDir = t.TempDir()
+	return d
+}
+
+// TestDownloadChunkFaultInjector covers downloadChunk's interaction with a
+// FaultInjector and its resume path, as one table of subtests so the
+// fallback/resume/checksum-mismatch/timeout cases share setup conventions
+// and read as one story instead of four unrelated top-level tests.
+func TestDownloadChunkFaultInjector(t *testing.T) {
+	t.Run("fallback to single connection on connection reset", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		d := newTestDownloader(t, srv, Options{
+			Connections:   2,
+			FaultInjector: &RandomFaultInjector{ConnResetRate: 1.0},
+		})
+
+		err := d.downloadChunk(0, chunk{start: 0, end: 9})
+		if err == nil {
+			t.Fatal("expected an error from a chunk whose BeforeRequest always fails")
+		}
+
+		d.multiConnectionMutex.Lock()
+		failed := d.multiConnectionFailed
+		d.multiConnectionMutex.Unlock()
+		if !failed {
+			t.Error("expected multiConnectionFailed to be set after a connection-error BeforeRequest with Connections > 1")
+		}
+	})
+
+	t.Run("resumes from existing part file", func(t *testing.T) {
+		const full = "ABCDEFGHIJ" // 10 bytes
+		const existing = "ABCD"   // first 4 bytes already on disk
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				// NewHTTPDownloader's resolveRedirects issues this; it's not
+				// the ranged GET under test.
+				return
+			}
+			if got, want := r.Header.Get("Range"), fmt.Sprintf("bytes=%d-9", len(existing)); got != want {
+				t.Errorf("Range header = %q, want %q", got, want)
+			}
+			remainder := full[len(existing):]
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-9/10", len(existing)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(remainder))
+		}))
+		defer srv.Close()
+
+		d := newTestDownloader(t, srv, Options{Connections: 1})
+		c := chunk{start: 0, end: int64(len(full) - 1)}
+
+		partPath := filepath.Join(d.tempDir, fmt.Sprintf("%s.part.%d.%d", filepath.Base(d.outPath), c.start, c.end))
+		if err := os.WriteFile(partPath, []byte(existing), 0644); err != nil {
+			t.Fatalf("seeding part file: %v", err)
+		}
+		d.chunks = []chunk{c}
+		d.chunkProgress = make([]int64, 1)
+
+		if err := d.downloadChunk(0, c); err != nil {
+			t.Fatalf("downloadChunk: %v", err)
+		}
+
+		got, err := os.ReadFile(partPath)
+		if err != nil {
+			t.Fatalf("reading resumed part file: %v", err)
+		}
+		if string(got) != full {
+			t.Errorf("resumed part file = %q, want %q", got, full)
+		}
+	})
+
+	t.Run("bit-flipped chunk fails checksum verification", func(t *testing.T) {
+		const content = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+		sum := sha256.Sum256([]byte(content))
+		wantSHA256 := hex.EncodeToString(sum[:])
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+				w.Header().Set("Accept-Ranges", "bytes")
+				return
+			}
+			http.ServeContent(w, r, "out.bin", time.Time{}, strings.NewReader(content))
+		}))
+		defer srv.Close()
+
+		d := newTestDownloader(t, srv, Options{
+			Connections:   2,
+			ChunkSize:     8,
+			SHA256:        wantSHA256,
+			FaultInjector: &RandomFaultInjector{BitFlipRate: 1.0},
+		})
+
+		if err := d.probe(); err != nil {
+			t.Fatalf("probe: %v", err)
+		}
+		if len(d.chunks) < 2 {
+			t.Fatalf("expected probe to split %d bytes into multiple chunks, got %d", len(content), len(d.chunks))
+		}
+
+		for i, c := range d.chunks {
+			if err := d.downloadChunk(i, c); err != nil {
+				t.Fatalf("downloadChunk %d: %v", i, err)
+			}
+		}
+		if err := d.assemble(); err != nil {
+			t.Fatalf("assemble: %v", err)
+		}
+
+		err := d.verifySHA256()
+		if err == nil {
+			t.Fatal("expected a SHA256 mismatch after every chunk was bit-flipped by CorruptRead")
+		}
+		if !strings.Contains(err.Error(), "SHA256 mismatch") {
+			t.Errorf("error = %q, want it to contain %q", err.Error(), "SHA256 mismatch")
+		}
+	})
+
+	t.Run("too many consecutive timeouts", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Range", "bytes 0-9/10")
+			w.WriteHeader(http.StatusPartialContent)
+		}))
+		defer srv.Close()
+
+		d := newTestDownloader(t, srv, Options{
+			Connections:   1,
+			FaultInjector: timeoutInjector{},
+		})
+		c := chunk{start: 0, end: 9}
+		d.chunks = []chunk{c}
+		d.chunkProgress = make([]int64, 1)
+
+		err := d.downloadChunk(0, c)
+		if err == nil {
+			t.Fatal("expected an error for a chunk that only ever times out")
+		}
+		if want := "too many consecutive read timeouts"; !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want it to contain %q", err.Error(), want)
+		}
+	})
+}
+
+// alwaysTimeoutBody is an io.ReadCloser that always fails with a
+// timeout-shaped error, simulating a connection that never delivers a byte.
+type alwaysTimeoutBody struct{}
+
+func (alwaysTimeoutBody) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("read tcp: i/o timeout")
+}
+
+func (alwaysTimeoutBody) Close() error { return nil }
+
+// timeoutInjector is a FaultInjector whose TransformResponse substitutes the
+// response body with one that never yields a byte - CorruptRead can't drive
+// this path, since it only runs on reads that return n > 0, and downloadChunk's
+// read loop resets consecutiveTimeouts to 0 on every such read.
+type timeoutInjector struct{}
+
+func (timeoutInjector) BeforeRequest(chunkIdx int) error { return nil }
+
+func (timeoutInjector) TransformResponse(chunkIdx int, resp *http.Response) *http.Response {
+	resp.Body = alwaysTimeoutBody{}
+	return resp
+}
+
+func (timeoutInjector) CorruptRead(chunkIdx, offset int, p []byte) error { return nil }
+
+var _ io.ReadCloser = alwaysTimeoutBody{}
@@ -0,0 +1,182 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// bufferedReader is a single chunk's worth of data staged in memory. Read
+// blocks until the chunk has fully arrived (or failed), so bytes are only
+// ever emitted in file order even though chunks download out of order.
+type bufferedReader struct {
+	buf   bytes.Buffer
+	ready chan struct{}
+	err   error
+}
+
+func newBufferedReader() *bufferedReader {
+	return &bufferedReader{ready: make(chan struct{})}
+}
+
+func (c *bufferedReader) Read(p []byte) (int, error) {
+	<-c.ready
+	if c.buf.Len() == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		return 0, io.EOF
+	}
+	return c.buf.Read(p)
+}
+
+func (c *bufferedReader) fill(r io.Reader, err error) {
+	if err == nil {
+		_, err = io.Copy(&c.buf, r)
+	}
+	c.err = err
+	close(c.ready)
+}
+
+// chanMultiReader reads a sequence of bufferedReaders received over a
+// channel, in order, advancing to the next one on EOF. Unlike io.MultiReader
+// it doesn't need the full list up front - chunks can still be queued while
+// earlier ones are being drained.
+type chanMultiReader struct {
+	readers <-chan io.Reader
+	current io.Reader
+	cancel  context.CancelFunc
+}
+
+func (m *chanMultiReader) Read(p []byte) (int, error) {
+	for {
+		if m.current == nil {
+			next, ok := <-m.readers
+			if !ok {
+				return 0, io.EOF
+			}
+			m.current = next
+		}
+		n, err := m.current.Read(p)
+		if n > 0 {
+			return n, nil
+		}
+		if err == io.EOF {
+			m.current = nil
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (m *chanMultiReader) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	return nil
+}
+
+// Fetch starts all ranged GETs through a workQueue bounded by d.concurrency
+// and returns immediately with an io.ReadCloser the caller can start draining
+// while later chunks are still in flight - the consumer can begin on chunk 0
+// as soon as it's fully buffered, without waiting for the last chunk's GET to
+// even be issued. This is a parallel entry point to Download(); it doesn't
+// touch d.tempDir or write anything to disk, and leaves the resumable
+// on-disk chunk behavior of Download()/downloadChunk() untouched.
+func (d *HTTPDownloader) Fetch(ctx context.Context) (io.ReadCloser, int64, error) {
+	if err := d.probe(); err != nil {
+		return nil, 0, err
+	}
+
+	if !d.acceptRanges || d.totalSize == 0 {
+		req, err := http.NewRequestWithContext(ctx, "GET", d.sourceURL, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			resp.Body.Close()
+			return nil, 0, fmt.Errorf("unexpected HTTP status: %d %s", resp.StatusCode, resp.Status)
+		}
+		return resp.Body, d.totalSize, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	readersCh := make(chan io.Reader, len(d.chunks))
+	buffers := make([]*bufferedReader, len(d.chunks))
+	for i := range d.chunks {
+		buffers[i] = newBufferedReader()
+		readersCh <- buffers[i]
+	}
+	close(readersCh)
+
+	queue := newWorkQueue(d.concurrency)
+	go func() {
+		for i, c := range d.chunks {
+			idx, ch := i, c
+			queue.schedule(func() {
+				d.fetchChunkIntoBuffer(ctx, idx, ch, buffers[idx])
+			})
+		}
+	}()
+
+	return &chanMultiReader{readers: readersCh, cancel: cancel}, d.totalSize, nil
+}
+
+// workQueue bounds how many scheduled jobs run concurrently, independent of
+// how many have been scheduled so far - the mechanism that lets Fetch create
+// every chunk's bufferedReader up front while still only issuing
+// d.concurrency ranged GETs at a time. schedule blocks once d.concurrency
+// jobs are in flight, so callers that need to return before every job is
+// queued (like Fetch) must call schedule from their own goroutine rather
+// than the one returning the reader.
+type workQueue struct {
+	sem chan struct{}
+}
+
+func newWorkQueue(concurrency int) *workQueue {
+	return &workQueue{sem: make(chan struct{}, concurrency)}
+}
+
+func (q *workQueue) schedule(fn func()) {
+	q.sem <- struct{}{}
+	go func() {
+		defer func() { <-q.sem }()
+		fn()
+	}()
+}
+
+// fetchChunkIntoBuffer issues a single ranged GET for c and streams the
+// response body into buf, unblocking any Read() waiting on it once done
+// (successfully or not). It doesn't retry or touch resumable part files -
+// that's the job of the disk-backed Download() path.
+func (d *HTTPDownloader) fetchChunkIntoBuffer(ctx context.Context, idx int, c chunk, buf *bufferedReader) {
+	req, err := http.NewRequestWithContext(ctx, "GET", d.sourceURL, nil)
+	if err != nil {
+		buf.fill(nil, err)
+		return
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start, c.end))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		buf.fill(nil, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		buf.fill(nil, fmt.Errorf("chunk %d: unexpected status %d", idx, resp.StatusCode))
+		return
+	}
+
+	buf.fill(resp.Body, nil)
+}
@@ -1,8 +1,10 @@
 package downloader
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +16,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 type HTTPDownloader struct {
@@ -32,32 +36,46 @@ type HTTPDownloader struct {
 	reporter       StatusReporter
 	downloadID     string // For state persistence
 
-	client          *http.Client
-	totalSize       int64
-	acceptRanges    bool
-	chunks          []chunk
-	chunkProgress   []int64
-	downloaded      int64
-	downloadedMutex sync.Mutex
-	chunkMutex      sync.Mutex
-	
+	client              *http.Client
+	totalSize           int64
+	acceptRanges        bool
+	chunks              []chunk
+	chunkProgress       []int64
+	chunkProgressReport []map[string]interface{} // one reused report map per chunk, shared across every chunk goroutine instead of each keeping its own copy
+	downloaded          int64
+	downloadedMutex     sync.Mutex
+	chunkMutex          sync.Mutex
+
 	// For accurate speed calculation across concurrent chunks
 	lastReportedDownloaded int64
 	lastReportedTime       time.Time
 	speedMutex             sync.Mutex
-	
+
 	// For detecting multi-connection issues
 	multiConnectionFailed bool
 	multiConnectionMutex  sync.Mutex
-	
+
 	// For connection failure tracking and retry
-	connectionFailures    int
-	maxConnectionFailures int
-	lastFailureTime       time.Time
+	connectionFailures     int
+	maxConnectionFailures  int
+	lastFailureTime        time.Time
 	connectionFailureMutex sync.Mutex
-	paused                bool
-	pauseReason           string
-	pauseMutex            sync.Mutex
+	retryPolicy            RetryPolicy
+	singleFileMode         bool
+
+	mirrors       []string
+	mirrorWeights map[string]float64
+	mirrorHealth  *mirrorHealth
+
+	bufferPool     *BufferPool
+	readBufferSize int
+	faultInjector  FaultInjector
+	rateLimiter    *rate.Limiter // nil disables throttling; shared across a Scheduler's jobs when opts.DownloadLimiter is set
+	paused         bool
+	pauseReason    string
+	pauseMutex     sync.Mutex
+
+	ctx context.Context // cancelling this aborts in-flight chunk/single-stream requests
 }
 
 type chunk struct {
@@ -75,6 +93,7 @@ func NewHTTPDownloader(sourceURL, outPath string, opts Options) *HTTPDownloader
 			transport.Proxy = http.ProxyURL(proxyURL)
 		}
 	}
+	applyTransportConfig(transport, opts.Transport)
 
 	// Set client timeout to a very long value (or 0 for no timeout)
 	// We manage read deadlines manually per chunk, so we don't want the client
@@ -84,7 +103,7 @@ func NewHTTPDownloader(sourceURL, outPath string, opts Options) *HTTPDownloader
 		// Use read timeout * 100 as a safety net (much longer than any single read)
 		clientTimeout = time.Duration(opts.ReadTimeout) * 100 * time.Second
 	}
-	
+
 	client := &http.Client{
 		Transport: transport,
 		Timeout:   clientTimeout, // Very long timeout - we manage read deadlines manually
@@ -99,30 +118,52 @@ func NewHTTPDownloader(sourceURL, outPath string, opts Options) *HTTPDownloader
 	}
 
 	downloader := &HTTPDownloader{
-		sourceURL:           sourceURL,
-		outPath:             outPath,
-		chunkSize:           opts.ChunkSize,
-		concurrency:         opts.Connections,
-		rateLimit:           opts.RateLimit,
-		proxy:               opts.Proxy,
-		retries:             opts.Retries,
-		connectTimeout:      time.Duration(opts.ConnectTimeout) * time.Second,
-		readTimeout:         time.Duration(opts.ReadTimeout) * time.Second,
-		sha256:              opts.SHA256,
-		quiet:               opts.Quiet,
-		reporter:            opts.StatusReporter,
-		downloadID:          opts.DownloadID,
-		client:              client,
-		lastReportedTime:    time.Now(),
+		sourceURL:             sourceURL,
+		outPath:               outPath,
+		chunkSize:             opts.ChunkSize,
+		concurrency:           opts.Connections,
+		rateLimit:             opts.RateLimit,
+		proxy:                 opts.Proxy,
+		retries:               opts.Retries,
+		connectTimeout:        time.Duration(opts.ConnectTimeout) * time.Second,
+		readTimeout:           time.Duration(opts.ReadTimeout) * time.Second,
+		sha256:                opts.SHA256,
+		quiet:                 opts.Quiet,
+		reporter:              opts.StatusReporter,
+		downloadID:            opts.DownloadID,
+		client:                client,
+		lastReportedTime:      time.Now(),
 		maxConnectionFailures: 10, // Max failures before pausing
+		retryPolicy:           opts.RetryPolicy,
+		singleFileMode:        opts.SingleFileMode,
+		mirrors:               opts.Mirrors,
+		mirrorWeights:         opts.MirrorWeights,
+		mirrorHealth:          newMirrorHealth(),
+		bufferPool:            opts.BufferPool,
+		readBufferSize:        opts.ReadBufferSize,
+		faultInjector:         opts.FaultInjector,
+		rateLimiter:           opts.DownloadLimiter,
+		ctx:                   opts.Context,
+	}
+	if downloader.ctx == nil {
+		downloader.ctx = context.Background()
+	}
+	if downloader.readBufferSize <= 0 {
+		downloader.readBufferSize = 65536
 	}
-	
+	if downloader.retryPolicy == nil {
+		downloader.retryPolicy = NewDefaultRetryPolicy(downloader.maxConnectionFailures)
+	}
+	if downloader.rateLimiter == nil && opts.RateLimit > 0 {
+		downloader.rateLimiter = rate.NewLimiter(rate.Limit(opts.RateLimit), int(opts.RateLimit))
+	}
+
 	// Resolve redirects and update sourceURL to final URL
 	if err := downloader.resolveRedirects(); err != nil {
 		// If redirect resolution fails, continue with original URL
 		// (some servers might not allow HEAD requests)
 	}
-	
+
 	return downloader
 }
 
@@ -149,6 +190,43 @@ func (d *HTTPDownloader) resolveRedirects() error {
 	return nil
 }
 
+// getBuffer returns a read buffer for chunk IO, drawing from d.bufferPool if
+// one was configured and allocating fresh otherwise.
+func (d *HTTPDownloader) getBuffer() []byte {
+	if d.bufferPool != nil {
+		return d.bufferPool.Get()
+	}
+	return make([]byte, d.readBufferSize)
+}
+
+// putBuffer returns buf to d.bufferPool; a no-op when no pool is configured.
+func (d *HTTPDownloader) putBuffer(buf []byte) {
+	if d.bufferPool != nil {
+		d.bufferPool.Put(buf)
+	}
+}
+
+// throttle blocks until d.rateLimiter has budget for n bytes, a no-op when
+// no rate limiter is configured. A single WaitN call can't request more than
+// the limiter's burst size, so n is split into burst-sized waits - this
+// matters when a Scheduler's shared limiter has a small burst relative to
+// the read buffer size of each job sharing it.
+func (d *HTTPDownloader) throttle(n int) {
+	if d.rateLimiter == nil || n <= 0 {
+		return
+	}
+	recordRateLimiterWait()
+	burst := d.rateLimiter.Burst()
+	for n > 0 {
+		take := n
+		if burst > 0 && take > burst {
+			take = burst
+		}
+		d.rateLimiter.WaitN(context.Background(), take)
+		n -= take
+	}
+}
+
 // Helper function to check if download is paused
 func (d *HTTPDownloader) isPaused() bool {
 	d.pauseMutex.Lock()
@@ -162,12 +240,12 @@ func (d *HTTPDownloader) pauseWithReason(reason string) {
 	d.paused = true
 	d.pauseReason = reason
 	d.pauseMutex.Unlock()
-	
+
 	if d.reporter != nil {
 		d.reporter.Report(map[string]interface{}{
-			"type":    "http",
-			"status":  "paused",
-			"message": reason,
+			"type":         "http",
+			"status":       "paused",
+			"message":      reason,
 			"pause_reason": reason,
 		})
 	}
@@ -188,40 +266,50 @@ func isConnectionError(err error) bool {
 		strings.Contains(errStr, "i/o timeout")
 }
 
-// Helper function to handle connection failure with retry logic
-func (d *HTTPDownloader) handleConnectionFailure(err error) error {
-	if !isConnectionError(err) {
-		return err
+// Helper function to handle connection failure with retry logic. resp is the
+// response that produced err, if any (nil for transport-level failures that
+// never got a response) - passing it through lets classifyError distinguish
+// a permanent HTTP status from a transient one instead of guessing from err
+// alone.
+func (d *HTTPDownloader) handleConnectionFailure(err error, resp *http.Response) error {
+	classified := classifyError(err, resp)
+
+	// Range-not-supported and permanent failures aren't worth counting
+	// against the connection-failure budget or retrying - the caller gets
+	// the classified error back so it can errors.As into the right type.
+	var rangeErr *RangeNotSupportedError
+	if errors.As(classified, &rangeErr) {
+		return classified
 	}
-	
+	var permErr *PermanentError
+	if errors.As(classified, &permErr) {
+		return classified
+	}
+
 	d.connectionFailureMutex.Lock()
 	d.connectionFailures++
 	lastFailure := d.lastFailureTime
 	d.lastFailureTime = time.Now()
 	failures := d.connectionFailures
 	d.connectionFailureMutex.Unlock()
-	
-	// If we've had too many failures, pause the download
-	if failures >= d.maxConnectionFailures {
-		reason := fmt.Sprintf("Connection lost: %s. Paused after %d failures. Please check your connection and resume manually.", err.Error(), failures)
-		d.pauseWithReason(reason)
-		return fmt.Errorf("connection lost: paused after %d failures", failures)
-	}
-	
-	// Exponential backoff: wait longer between retries
+
 	// Reset counter if last failure was more than 30 seconds ago (connection recovered)
 	if !lastFailure.IsZero() && time.Since(lastFailure) > 30*time.Second {
 		d.connectionFailureMutex.Lock()
 		d.connectionFailures = 1 // Reset to 1 (current failure)
 		d.connectionFailureMutex.Unlock()
+		failures = 1
 	}
-	
-	// Exponential backoff: 1s, 2s, 4s, 8s, etc., max 30s
-	backoff := time.Duration(1<<uint(failures-1)) * time.Second
-	if backoff > 30*time.Second {
-		backoff = 30 * time.Second
+
+	retry, backoff := d.retryPolicy.ShouldRetry(failures-1, classified)
+
+	// If we've had too many failures, or the policy gave up, pause the download
+	if !retry || failures >= d.maxConnectionFailures {
+		reason := fmt.Sprintf("Connection lost: %s. Paused after %d failures. Please check your connection and resume manually.", err.Error(), failures)
+		d.pauseWithReason(reason)
+		return fmt.Errorf("connection lost: paused after %d failures", failures)
 	}
-	
+
 	// Report retrying status
 	if d.reporter != nil {
 		d.reporter.Report(map[string]interface{}{
@@ -230,9 +318,10 @@ func (d *HTTPDownloader) handleConnectionFailure(err error) error {
 			"message": fmt.Sprintf("Connection lost, retrying in %v... (attempt %d/%d)", backoff, failures, d.maxConnectionFailures),
 		})
 	}
-	
+
+	recordRetry()
 	time.Sleep(backoff)
-	return err // Return error to trigger retry
+	return classified // Return the classified error to trigger retry
 }
 
 // Helper function to reset connection failure counter on success
@@ -246,7 +335,7 @@ func (d *HTTPDownloader) Download() error {
 	// Check if final file already exists and verify it
 	if info, err := os.Stat(d.outPath); err == nil {
 		existingSize := info.Size()
-		
+
 		if d.reporter != nil {
 			d.reporter.Report(map[string]interface{}{
 				"type":          "http",
@@ -256,7 +345,7 @@ func (d *HTTPDownloader) Download() error {
 				"downloaded":    existingSize,
 			})
 		}
-		
+
 		// Verify existing file if SHA256 is provided
 		if d.sha256 != "" {
 			if d.reporter != nil {
@@ -289,18 +378,18 @@ func (d *HTTPDownloader) Download() error {
 			// We'll verify size after probe
 		}
 	}
-	
+
 	// Create temp directory for chunks (hidden folder in destination directory)
 	destDir := filepath.Dir(d.outPath)
 	fileName := filepath.Base(d.outPath)
 	tempDirName := fmt.Sprintf(".accelara-temp-%s", fileName)
 	d.tempDir = filepath.Join(destDir, tempDirName)
-	
+
 	// Create temp directory
 	if err := os.MkdirAll(d.tempDir, 0755); err != nil {
 		return fmt.Errorf("failed to create temp directory: %s", err)
 	}
-	
+
 	// Ensure temp directory is cleaned up on error
 	defer func() {
 		// Only remove if download failed (check if final file exists)
@@ -312,7 +401,7 @@ func (d *HTTPDownloader) Download() error {
 	if err := d.probe(); err != nil {
 		return err
 	}
-	
+
 	// After probe, check if existing file size matches expected size
 	if info, err := os.Stat(d.outPath); err == nil && d.totalSize > 0 {
 		if info.Size() == d.totalSize {
@@ -375,6 +464,10 @@ func (d *HTTPDownloader) Download() error {
 		return d.downloadSingle()
 	}
 
+	if d.singleFileMode {
+		return d.downloadDirect()
+	}
+
 	return d.downloadSegmented()
 }
 
@@ -407,18 +500,18 @@ func (d *HTTPDownloader) probe() error {
 			return fmt.Errorf("failed to probe URL: HEAD returned %d, GET failed: %s", resp.StatusCode, err)
 		}
 		defer resp2.Body.Close()
-		
+
 		// Update sourceURL again if GET request was redirected
 		finalURL2 := resp2.Request.URL.String()
 		if finalURL2 != d.sourceURL {
 			// Update sourceURL silently (no UI log)
 			d.sourceURL = finalURL2
 		}
-		
+
 		if resp2.StatusCode < 200 || resp2.StatusCode >= 300 {
 			return fmt.Errorf("unexpected HTTP status: %d %s", resp2.StatusCode, resp2.Status)
 		}
-		
+
 		// Use response from GET request
 		resp = resp2
 	}
@@ -471,20 +564,21 @@ func (d *HTTPDownloader) probe() error {
 		if maxChunks < 1 {
 			maxChunks = 1
 		}
-		
+
 		// Calculate chunk size based on max chunks
 		chunkSize := (d.totalSize + int64(maxChunks) - 1) / int64(maxChunks)
 		if chunkSize < d.chunkSize {
 			chunkSize = d.chunkSize
 		}
-		
+
 		count := (d.totalSize + chunkSize - 1) / chunkSize
 		if count > int64(maxChunks) {
 			count = int64(maxChunks)
 		}
-		
+
 		d.chunks = make([]chunk, count)
 		d.chunkProgress = make([]int64, count)
+		d.chunkProgressReport = make([]map[string]interface{}, count)
 		for i := int64(0); i < count; i++ {
 			start := i * chunkSize
 			end := start + chunkSize - 1
@@ -508,12 +602,18 @@ func (d *HTTPDownloader) downloadSingle() error {
 	}
 	defer file.Close()
 
-	req, _ := http.NewRequest("GET", d.sourceURL, nil)
+	req, err := http.NewRequestWithContext(d.ctx, "GET", d.sourceURL, nil)
+	if err != nil {
+		return err
+	}
 	resp, err := d.client.Do(req)
 	if err != nil {
+		if d.ctx.Err() != nil {
+			return d.ctx.Err()
+		}
 		// Handle connection errors with retry logic
 		if isConnectionError(err) {
-			return d.handleConnectionFailure(err)
+			return d.handleConnectionFailure(err, nil)
 		}
 		return err
 	}
@@ -528,7 +628,8 @@ func (d *HTTPDownloader) downloadSingle() error {
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected HTTP status: %d %s", resp.StatusCode, resp.Status)
+		statusErr := fmt.Errorf("unexpected HTTP status: %d %s", resp.StatusCode, resp.Status)
+		return d.handleConnectionFailure(statusErr, resp)
 	}
 
 	// Update totalSize from response Content-Length if available and not already set
@@ -547,7 +648,8 @@ func (d *HTTPDownloader) downloadSingle() error {
 		d.totalSize = resp.ContentLength
 	}
 
-	buf := make([]byte, 65536)
+	buf := d.getBuffer()
+	defer d.putBuffer(buf)
 	lastUpdate := time.Now()
 	lastDownloaded := int64(0)
 
@@ -556,15 +658,21 @@ func (d *HTTPDownloader) downloadSingle() error {
 		if d.isPaused() {
 			return fmt.Errorf("download paused: %s", d.pauseReason)
 		}
-		
+		if d.ctx.Err() != nil {
+			return d.ctx.Err()
+		}
+
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
+			d.throttle(n)
 			file.Write(buf[:n])
+			recordBytesRead(int64(n))
+			recordBytesWritten(int64(n))
 			d.downloadedMutex.Lock()
 			d.downloaded += int64(n)
 			downloaded := d.downloaded
 			d.downloadedMutex.Unlock()
-			
+
 			// Reset connection failures on successful read
 			d.resetConnectionFailures()
 
@@ -580,7 +688,8 @@ func (d *HTTPDownloader) downloadSingle() error {
 				if progress > 1.0 {
 					progress = 1.0
 				}
-				
+				setDownloadProgress(d.downloadID, progress)
+
 				// Calculate ETA based on current speed
 				var eta float64 = 0
 				if speed > 0 && d.totalSize > 0 && downloaded < d.totalSize {
@@ -598,12 +707,12 @@ func (d *HTTPDownloader) downloadSingle() error {
 					"download_rate": int64(speed),
 					"eta":           eta,
 				}
-				
+
 				// Add SHA256 for state persistence if available
 				if d.sha256 != "" {
 					reportData["sha256"] = d.sha256
 				}
-				
+
 				d.reporter.Report(reportData)
 				lastUpdate = now
 				lastDownloaded = downloaded
@@ -622,10 +731,10 @@ func (d *HTTPDownloader) downloadSingle() error {
 					"error":   err.Error(),
 				})
 			}
-			
+
 			// Handle connection errors - pause download
 			if isConnectionError(err) {
-				retryErr := d.handleConnectionFailure(err)
+				retryErr := d.handleConnectionFailure(err, nil)
 				if retryErr != nil && strings.Contains(retryErr.Error(), "paused") {
 					// Download was paused - don't complete, return error
 					return retryErr
@@ -639,7 +748,7 @@ func (d *HTTPDownloader) downloadSingle() error {
 			return err
 		}
 	}
-	
+
 	// Check if paused before completing
 	if d.isPaused() {
 		return fmt.Errorf("download paused: %s", d.pauseReason)
@@ -660,17 +769,17 @@ func (d *HTTPDownloader) downloadSingle() error {
 	// If we have an expected totalSize, verify it matches
 	if d.totalSize > 0 {
 		if actualSize != d.totalSize {
-			return fmt.Errorf("download incomplete: expected %d bytes, downloaded %d bytes (%.2f%%)", 
+			return fmt.Errorf("download incomplete: expected %d bytes, downloaded %d bytes (%.2f%%)",
 				d.totalSize, actualSize, float64(actualSize)/float64(d.totalSize)*100)
 		}
 		if downloaded != d.totalSize {
-			return fmt.Errorf("download size mismatch: expected %d bytes, tracked %d bytes", 
+			return fmt.Errorf("download size mismatch: expected %d bytes, tracked %d bytes",
 				d.totalSize, downloaded)
 		}
 	} else {
 		// No Content-Length - verify downloaded matches file size
 		if downloaded != actualSize {
-			return fmt.Errorf("download size mismatch: tracked %d bytes, file size %d bytes", 
+			return fmt.Errorf("download size mismatch: tracked %d bytes, file size %d bytes",
 				downloaded, actualSize)
 		}
 		// For downloads without Content-Length, check if file is suspiciously small
@@ -683,10 +792,10 @@ func (d *HTTPDownloader) downloadSingle() error {
 			n, _ := file.Read(header)
 			file.Seek(0, 0)
 			headerStr := string(header[:n])
-			if strings.Contains(headerStr, "<html") || 
-			   strings.Contains(headerStr, "<!DOCTYPE") ||
-			   strings.Contains(headerStr, "\"error\"") ||
-			   strings.Contains(headerStr, "error") {
+			if strings.Contains(headerStr, "<html") ||
+				strings.Contains(headerStr, "<!DOCTYPE") ||
+				strings.Contains(headerStr, "\"error\"") ||
+				strings.Contains(headerStr, "error") {
 				return fmt.Errorf("download appears to be an error page (16KB HTML/JSON), not the actual file")
 			}
 		}
@@ -696,7 +805,7 @@ func (d *HTTPDownloader) downloadSingle() error {
 	if err := os.Rename(tempPath, d.outPath); err != nil {
 		return fmt.Errorf("failed to move file to destination: %s", err)
 	}
-	
+
 	// Verify SHA256 if provided
 	if d.sha256 != "" {
 		if d.reporter != nil {
@@ -721,10 +830,10 @@ func (d *HTTPDownloader) downloadSingle() error {
 			})
 		}
 	}
-	
+
 	// Clean up temp directory
 	os.RemoveAll(d.tempDir)
-	
+
 	return nil
 }
 
@@ -733,11 +842,11 @@ func (d *HTTPDownloader) downloadSegmented() error {
 	var wg sync.WaitGroup
 	failedChunks := make(map[int]error)
 	failedMutex := sync.Mutex{}
-	
+
 	// Track connection/timeout failures to detect blocking
 	connectionFailureCount := 0
 	failureMutex := sync.Mutex{}
-	
+
 	// Threshold: if more than 50% of chunks fail with connection/timeout errors, fallback
 	failureThreshold := len(d.chunks) / 2
 	if failureThreshold < 1 {
@@ -750,7 +859,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			
+
 			// Add panic recovery with memory logging
 			defer func() {
 				if r := recover(); r != nil {
@@ -775,69 +884,77 @@ func (d *HTTPDownloader) downloadSegmented() error {
 					failedMutex.Unlock()
 					return
 				}
-				
+				if d.ctx.Err() != nil {
+					failedMutex.Lock()
+					failedChunks[idx] = d.ctx.Err()
+					failedMutex.Unlock()
+					return
+				}
+
 				err := d.downloadChunk(idx, c)
 				if err == nil {
 					// Reset connection failures on success
 					d.resetConnectionFailures()
 					return
 				}
-				
+
 				// Check if this is a multi-connection rejection
 				errStr := err.Error()
-				if strings.Contains(errStr, "multi-connection may not be allowed") || 
-				   strings.Contains(errStr, "range requests may not be supported") {
+				if strings.Contains(errStr, "multi-connection may not be allowed") ||
+					strings.Contains(errStr, "range requests may not be supported") {
 					failedMutex.Lock()
 					failedChunks[idx] = err
 					failedMutex.Unlock()
-					
+
 					// Mark as failed and break retry loop
 					d.multiConnectionMutex.Lock()
 					d.multiConnectionFailed = true
 					d.multiConnectionMutex.Unlock()
 					return
 				}
-				
+
 				// Check if this is a connection/timeout error that might indicate blocking
 				isConnErr := isConnectionError(err)
-				isTimeoutErr := strings.Contains(errStr, "timeout") || 
-				               strings.Contains(errStr, "deadline exceeded") ||
-				               strings.Contains(errStr, "context deadline exceeded") ||
-				               strings.Contains(errStr, "Client.Timeout") ||
-				               strings.Contains(errStr, "too many consecutive read timeouts")
-				
+				isTimeoutErr := strings.Contains(errStr, "timeout") ||
+					strings.Contains(errStr, "deadline exceeded") ||
+					strings.Contains(errStr, "context deadline exceeded") ||
+					strings.Contains(errStr, "Client.Timeout") ||
+					strings.Contains(errStr, "too many consecutive read timeouts")
+
 				if isConnErr || isTimeoutErr {
 					// Track connection failures
 					failureMutex.Lock()
 					connectionFailureCount++
 					shouldFallback := connectionFailureCount >= failureThreshold
 					failureMutex.Unlock()
-					
-					// If too many chunks are failing with connection errors, likely being blocked
-					if shouldFallback && d.concurrency > 1 {
+
+					// If too many chunks are failing with connection errors, likely being
+					// blocked. With mirrors configured, per-mirror health already routes
+					// around a bad endpoint, so don't also collapse to single connection.
+					if shouldFallback && d.concurrency > 1 && len(d.mirrors) == 0 {
 						d.multiConnectionMutex.Lock()
 						d.multiConnectionFailed = true
 						d.multiConnectionMutex.Unlock()
-						
+
 						if d.reporter != nil {
 							d.reporter.Report(map[string]interface{}{
-								"type":    "http",
-								"status":  "warning",
+								"type":   "http",
+								"status": "warning",
 								"message": fmt.Sprintf("Multiple chunks failing with connection errors (%d/%d). Server may be blocking multiple connections. Falling back to single connection...",
 									connectionFailureCount, len(d.chunks)),
 							})
 						}
-						
+
 						// Mark this chunk as failed and return to trigger fallback
 						failedMutex.Lock()
 						failedChunks[idx] = err
 						failedMutex.Unlock()
 						return
 					}
-					
+
 					// Handle connection errors with retry logic
 					if isConnErr {
-						retryErr := d.handleConnectionFailure(err)
+						retryErr := d.handleConnectionFailure(err, nil)
 						if retryErr != nil && strings.Contains(retryErr.Error(), "paused") {
 							// Download was paused due to too many failures
 							failedMutex.Lock()
@@ -848,7 +965,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 						// Continue retry loop
 						continue
 					}
-					
+
 					// For timeout errors, continue retry loop
 					if isTimeoutErr {
 						if attempt < d.retries {
@@ -857,7 +974,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 						}
 					}
 				}
-				
+
 				if attempt < d.retries {
 					time.Sleep(time.Duration(attempt+1) * 500 * time.Millisecond)
 				} else {
@@ -870,7 +987,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 	}
 
 	wg.Wait()
-	
+
 	// Log chunk status after all goroutines finish (simplified - only show incomplete parts)
 	if d.reporter != nil && len(failedChunks) > 0 {
 		d.chunkMutex.Lock()
@@ -883,7 +1000,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 			}
 		}
 		d.chunkMutex.Unlock()
-		
+
 		if len(incompleteParts) > 0 {
 			d.reporter.Report(map[string]interface{}{
 				"type":    "http",
@@ -892,12 +1009,12 @@ func (d *HTTPDownloader) downloadSegmented() error {
 			})
 		}
 	}
-	
+
 	// Check if multi-connection failed and fall back to single connection
 	d.multiConnectionMutex.Lock()
 	shouldFallback := d.multiConnectionFailed && len(failedChunks) > 0
 	d.multiConnectionMutex.Unlock()
-	
+
 	if shouldFallback {
 		// Report fallback to user
 		if d.reporter != nil {
@@ -907,20 +1024,21 @@ func (d *HTTPDownloader) downloadSegmented() error {
 				"message": "Server disallows multiple connections, falling back to single connection",
 			})
 		}
-		
+
 		// Clean up partial chunks before falling back to single connection
 		fileName := filepath.Base(d.outPath)
 		for _, c := range d.chunks {
 			partPath := filepath.Join(d.tempDir, fmt.Sprintf("%s.part.%d.%d", fileName, c.start, c.end))
 			os.Remove(partPath) // Ignore errors - file may not exist
 		}
-		
+
 		// Reset progress tracking
 		d.chunkMutex.Lock()
 		d.chunkProgress = nil
+		d.chunkProgressReport = nil
 		d.chunks = nil
 		d.chunkMutex.Unlock()
-		
+
 		// Fall back to single connection mode
 		return d.downloadSingle()
 	}
@@ -933,11 +1051,11 @@ func (d *HTTPDownloader) downloadSegmented() error {
 	totalChunkDownloaded := int64(0)
 	var incompleteChunks []int
 	var chunkDetails []string
-	
+
 	for i := range d.chunks {
 		chunkSize := d.chunks[i].end - d.chunks[i].start + 1
 		progress := d.chunkProgress[i]
-		
+
 		// Check actual file size
 		partPath := filepath.Join(d.tempDir, fmt.Sprintf("%s.part.%d.%d", fileName, d.chunks[i].start, d.chunks[i].end))
 		fileInfo, fileErr := os.Stat(partPath)
@@ -945,7 +1063,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 		if fileInfo != nil {
 			fileSize = fileInfo.Size()
 		}
-		
+
 		// Use file size if it's larger than tracked progress (chunk may have completed but progress not updated)
 		if fileSize > progress {
 			if d.reporter != nil {
@@ -958,11 +1076,11 @@ func (d *HTTPDownloader) downloadSegmented() error {
 			d.chunkProgress[i] = fileSize
 			progress = fileSize
 		}
-		
+
 		if progress < chunkSize {
 			allChunksComplete = false
 			incompleteChunks = append(incompleteChunks, i)
-			chunkDetails = append(chunkDetails, fmt.Sprintf("chunk %d: progress=%d/%d, file=%d bytes, exists=%v", 
+			chunkDetails = append(chunkDetails, fmt.Sprintf("chunk %d: progress=%d/%d, file=%d bytes, exists=%v",
 				i, progress, chunkSize, fileSize, fileErr == nil))
 		} else {
 			chunkDetails = append(chunkDetails, fmt.Sprintf("chunk %d: complete (%d/%d bytes)", i, progress, chunkSize))
@@ -970,16 +1088,16 @@ func (d *HTTPDownloader) downloadSegmented() error {
 		totalChunkDownloaded += progress
 	}
 	d.chunkMutex.Unlock()
-	
+
 	// Update total downloaded from chunk progress
 	d.downloadedMutex.Lock()
 	d.downloaded = totalChunkDownloaded
 	d.downloadedMutex.Unlock()
-	
+
 	if !allChunksComplete {
-		errorMsg := fmt.Sprintf("not all chunks completed: chunks %v incomplete, downloaded %d of %d bytes. Details: %s", 
+		errorMsg := fmt.Sprintf("not all chunks completed: chunks %v incomplete, downloaded %d of %d bytes. Details: %s",
 			incompleteChunks, totalChunkDownloaded, d.totalSize, strings.Join(chunkDetails, "; "))
-		
+
 		if d.reporter != nil {
 			var m runtime.MemStats
 			runtime.ReadMemStats(&m)
@@ -992,7 +1110,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 		}
 		return fmt.Errorf(errorMsg)
 	}
-	
+
 	// Verify total downloaded matches expected (allow small rounding differences)
 	if totalChunkDownloaded < d.totalSize-1024 || totalChunkDownloaded > d.totalSize+1024 {
 		return fmt.Errorf("download size mismatch: expected %d bytes, downloaded %d bytes", d.totalSize, totalChunkDownloaded)
@@ -1000,13 +1118,13 @@ func (d *HTTPDownloader) downloadSegmented() error {
 
 	if d.reporter != nil {
 		d.reporter.Report(map[string]interface{}{
-			"type":              "http",
-			"status":            "verifying",
-			"progress":          1.0,
-			"verify_status":     "chunks_verified",
-			"downloaded":        d.downloaded,
-			"total":             d.totalSize,
-			"chunk_total_size":  totalChunkDownloaded,
+			"type":             "http",
+			"status":           "verifying",
+			"progress":         1.0,
+			"verify_status":    "chunks_verified",
+			"downloaded":       d.downloaded,
+			"total":            d.totalSize,
+			"chunk_total_size": totalChunkDownloaded,
 		})
 	}
 
@@ -1044,7 +1162,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 		"total":      d.totalSize,
 		"verified":   true,
 	}
-	
+
 	// Add SHA256 for state persistence if available
 	if d.sha256 != "" {
 		reportData["sha256"] = d.sha256
@@ -1052,7 +1170,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 	} else {
 		reportData["verify_status"] = "size_verified"
 	}
-	
+
 	if d.reporter != nil {
 		d.reporter.Report(reportData)
 	}
@@ -1062,7 +1180,7 @@ func (d *HTTPDownloader) downloadSegmented() error {
 
 func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 	// Removed verbose logging - chunk progress is shown in status updates
-	
+
 	// Store chunks in temp directory
 	fileName := filepath.Base(d.outPath)
 	partPath := filepath.Join(d.tempDir, fmt.Sprintf("%s.part.%d.%d", fileName, c.start, c.end))
@@ -1073,7 +1191,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 	var err error
 	var start int64 = c.start
 	var chunkDownloaded int64 = 0
-	
+
 	if info, err := os.Stat(partPath); err == nil {
 		if info.Size() >= expectedChunkSize {
 			// Chunk is already complete, update progress and return
@@ -1085,7 +1203,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 				totalDownloaded += d.chunkProgress[i]
 			}
 			d.chunkMutex.Unlock()
-			
+
 			d.downloadedMutex.Lock()
 			d.downloaded = totalDownloaded
 			d.downloadedMutex.Unlock()
@@ -1095,7 +1213,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 		existingSize := info.Size()
 		chunkDownloaded = existingSize
 		start = c.start + existingSize
-		
+
 		// Open file in append mode to continue downloading
 		file, err = os.OpenFile(partPath, os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
@@ -1117,66 +1235,114 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 	}
 	defer file.Close()
 
-	req, _ := http.NewRequest("GET", d.sourceURL, nil)
+	if d.ctx.Err() != nil {
+		return d.ctx.Err()
+	}
+
+	mirrorURL := d.pickMirrorURL(c)
+	usingMirror := len(d.mirrors) > 0
+
+	req, err := http.NewRequestWithContext(d.ctx, "GET", mirrorURL, nil)
+	if err != nil {
+		return err
+	}
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, c.end))
 
+	if d.faultInjector != nil {
+		if err := d.faultInjector.BeforeRequest(idx); err != nil {
+			if isConnectionError(err) && d.concurrency > 1 {
+				if usingMirror {
+					d.mirrorHealth.recordFailure(mirrorURL)
+				} else {
+					d.multiConnectionMutex.Lock()
+					d.multiConnectionFailed = true
+					d.multiConnectionMutex.Unlock()
+				}
+			}
+			if isConnectionError(err) {
+				return d.handleConnectionFailure(err, nil)
+			}
+			return err
+		}
+	}
+
 	resp, err := d.client.Do(req)
+	if err == nil && d.faultInjector != nil {
+		resp = d.faultInjector.TransformResponse(idx, resp)
+	}
 	if err != nil {
 		// Check if it's a connection error that might indicate multi-connection issues
 		errStr := err.Error()
-		if (strings.Contains(errStr, "connection reset") || 
-		    strings.Contains(errStr, "connection refused") ||
-		    strings.Contains(errStr, "timeout")) && d.concurrency > 1 {
-			// Multiple connection errors might indicate server doesn't allow it
-			d.multiConnectionMutex.Lock()
-			d.multiConnectionFailed = true
-			d.multiConnectionMutex.Unlock()
+		if (strings.Contains(errStr, "connection reset") ||
+			strings.Contains(errStr, "connection refused") ||
+			strings.Contains(errStr, "timeout")) && d.concurrency > 1 {
+			if usingMirror {
+				// A per-mirror health tracker routes around this mirror
+				// instead of disabling multi-connection for the whole download.
+				d.mirrorHealth.recordFailure(mirrorURL)
+			} else {
+				// Multiple connection errors might indicate server doesn't allow it
+				d.multiConnectionMutex.Lock()
+				d.multiConnectionFailed = true
+				d.multiConnectionMutex.Unlock()
+			}
 		}
 		// Handle connection errors with retry logic
 		if isConnectionError(err) {
-			return d.handleConnectionFailure(err)
+			return d.handleConnectionFailure(err, nil)
 		}
 		return err
 	}
 	defer resp.Body.Close()
 
-	// Update sourceURL to final URL after redirects (in case redirects happen during chunk download)
-	finalURL := resp.Request.URL.String()
-	if finalURL != d.sourceURL {
-		// Update sourceURL silently (no UI log)
-		d.sourceURL = finalURL
+	// Update sourceURL to final URL after redirects (in case redirects happen
+	// during chunk download). Skip this when using a mirror - a mirror's own
+	// redirect target isn't a replacement for the primary source URL.
+	if !usingMirror {
+		finalURL := resp.Request.URL.String()
+		if finalURL != d.sourceURL {
+			// Update sourceURL silently (no UI log)
+			d.sourceURL = finalURL
+		}
 	}
 
 	// Check if server disallows multiple connections
-	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests || 
-	   resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusBadRequest {
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusBadRequest {
+		if usingMirror {
+			d.mirrorHealth.recordFailure(mirrorURL)
+			return fmt.Errorf("mirror %s rejected request (status %d)", mirrorURL, resp.StatusCode)
+		}
 		// Server rejected the request - likely doesn't allow multiple connections
 		d.multiConnectionMutex.Lock()
 		d.multiConnectionFailed = true
 		d.multiConnectionMutex.Unlock()
 		return fmt.Errorf("server rejected request (status %d): multi-connection may not be allowed", resp.StatusCode)
 	}
-	
+
 	// If we requested a range but got 200 OK instead of 206 Partial Content, server may not support ranges
 	if resp.StatusCode == http.StatusOK && start > c.start {
 		// We requested a range but got full content - server doesn't support ranges properly
 		d.multiConnectionMutex.Lock()
 		d.multiConnectionFailed = true
 		d.multiConnectionMutex.Unlock()
-		return fmt.Errorf("server returned 200 OK instead of 206 Partial Content: range requests may not be supported")
+		rangeErr := fmt.Errorf("server returned 200 OK instead of 206 Partial Content: range requests may not be supported")
+		return d.handleConnectionFailure(rangeErr, resp)
 	}
-	
+
 	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+		statusErr := fmt.Errorf("unexpected status: %d", resp.StatusCode)
+		return d.handleConnectionFailure(statusErr, resp)
 	}
 
-	buf := make([]byte, 65536)
+	buf := d.getBuffer()
+	defer d.putBuffer(buf)
 	lastUpdate := time.Now()
 	readCount := 0
 	consecutiveTimeouts := 0
 	maxConsecutiveTimeouts := 10 // Fail after 10 consecutive timeouts
 	lastProgressTime := time.Now()
-	
+
 	// Update progress with existing chunk size if resuming
 	if chunkDownloaded > 0 {
 		d.chunkMutex.Lock()
@@ -1184,10 +1350,20 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 		d.chunkMutex.Unlock()
 		lastProgressTime = time.Now()
 	}
-	
-	// Pre-allocate chunkProgress slice to avoid repeated allocations
-	chunkProgress := make([]map[string]interface{}, len(d.chunks))
-	
+
+	// chunkProgressReport is shared across every chunk's goroutine (guarded by
+	// d.chunkMutex) instead of each one keeping its own copy of every chunk's
+	// report map, so reporting N chunks' progress allocates N maps total
+	// instead of N per concurrently-downloading chunk. Sized here rather
+	// than assumed pre-allocated, since tests call downloadChunk directly
+	// without going through the probe() setup that normally sizes it.
+	d.chunkMutex.Lock()
+	if len(d.chunkProgressReport) != len(d.chunks) {
+		d.chunkProgressReport = make([]map[string]interface{}, len(d.chunks))
+	}
+	chunkProgress := d.chunkProgressReport
+	d.chunkMutex.Unlock()
+
 	// Set a longer read deadline for the entire chunk download
 	// This prevents individual read operations from timing out on slow connections
 	// We'll use 2x the configured read timeout to allow for slow but steady progress
@@ -1197,10 +1373,10 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 		tcpConn = conn
 		tcpConn.SetReadDeadline(readDeadline)
 	}
-	
+
 	for {
 		readCount++
-		
+
 		// Extend read deadline periodically to prevent timeout on slow connections
 		if readCount%100 == 0 {
 			newDeadline := time.Now().Add(d.readTimeout * 2)
@@ -1208,15 +1384,22 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 				tcpConn.SetReadDeadline(newDeadline)
 			}
 		}
-		
+
 		// Removed verbose memory logging - chunk progress is shown in status updates
-		
+
 		n, err := resp.Body.Read(buf)
+		if n > 0 && d.faultInjector != nil {
+			if injErr := d.faultInjector.CorruptRead(idx, int(chunkDownloaded), buf[:n]); injErr != nil && err == nil {
+				err = injErr
+			}
+		}
 		if n > 0 {
+			d.throttle(n)
+
 			// Reset timeout counter and update progress time on successful read
 			consecutiveTimeouts = 0
 			lastProgressTime = time.Now()
-			
+
 			written, writeErr := file.Write(buf[:n])
 			if writeErr != nil {
 				// Log error
@@ -1229,9 +1412,9 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 				}
 				return fmt.Errorf("chunk %d write error at %d bytes: %w", idx, chunkDownloaded, writeErr)
 			}
-			
+
 			chunkDownloaded += int64(written)
-			
+
 			d.chunkMutex.Lock()
 			d.chunkProgress[idx] = chunkDownloaded
 			// Calculate total downloaded from all chunk progress to avoid double-counting
@@ -1239,7 +1422,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 			for i := range d.chunkProgress {
 				totalDownloaded += d.chunkProgress[i]
 			}
-			
+
 			// Reuse chunkProgress slice instead of allocating new one
 			for i := range d.chunks {
 				chunkSize := d.chunks[i].end - d.chunks[i].start + 1
@@ -1254,7 +1437,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 				chunkProgress[i]["total"] = chunkSize
 			}
 			d.chunkMutex.Unlock()
-			
+
 			// Update total downloaded from chunk progress
 			d.downloadedMutex.Lock()
 			d.downloaded = totalDownloaded
@@ -1269,7 +1452,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 				if progress > 1.0 {
 					progress = 1.0
 				}
-				
+
 				// Calculate speed from total downloaded using global tracking
 				// This ensures consistent speed calculation across all concurrent chunks
 				d.speedMutex.Lock()
@@ -1283,32 +1466,32 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 				d.lastReportedDownloaded = downloaded
 				d.lastReportedTime = now
 				d.speedMutex.Unlock()
-				
+
 				// Calculate ETA based on current speed
 				var eta float64 = 0
 				if speed > 0 && d.totalSize > 0 && downloaded < d.totalSize {
 					remaining := d.totalSize - downloaded
 					eta = float64(remaining) / float64(speed)
 				}
-				
+
 				reportData := map[string]interface{}{
-					"type":          "http",
-					"status":        "downloading",
-					"progress":      progress,
-					"downloaded":    downloaded,
-					"total":         d.totalSize,
-					"speed":         speed,
-					"download_rate": speed,
+					"type":           "http",
+					"status":         "downloading",
+					"progress":       progress,
+					"downloaded":     downloaded,
+					"total":          d.totalSize,
+					"speed":          speed,
+					"download_rate":  speed,
 					"chunk_progress": chunkProgress,
-					"eta":           eta,
-					"chunk_count":   len(d.chunks),
+					"eta":            eta,
+					"chunk_count":    len(d.chunks),
 				}
-				
+
 				// Add SHA256 for state persistence if available
 				if d.sha256 != "" {
 					reportData["sha256"] = d.sha256
 				}
-				
+
 				d.reporter.Report(reportData)
 				lastUpdate = time.Now()
 			}
@@ -1320,28 +1503,31 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 		if err != nil {
 			// Check if it's a timeout error - these can be retried
 			errStr := err.Error()
-			isTimeout := strings.Contains(errStr, "timeout") || 
-			            strings.Contains(errStr, "deadline exceeded") ||
-			            strings.Contains(errStr, "context deadline exceeded") ||
-			            strings.Contains(errStr, "Client.Timeout") ||
-			            strings.Contains(errStr, "i/o timeout")
-			
+			isTimeout := strings.Contains(errStr, "timeout") ||
+				strings.Contains(errStr, "deadline exceeded") ||
+				strings.Contains(errStr, "context deadline exceeded") ||
+				strings.Contains(errStr, "Client.Timeout") ||
+				strings.Contains(errStr, "i/o timeout")
+
 			// For timeout errors, track consecutive timeouts and progress
 			if isTimeout {
 				consecutiveTimeouts++
 				timeSinceProgress := time.Since(lastProgressTime)
-				
+
 				// If we've had too many consecutive timeouts or no progress for too long, fail the chunk
 				// This allows the retry mechanism to handle it at a higher level
 				if consecutiveTimeouts >= maxConsecutiveTimeouts || timeSinceProgress > d.readTimeout*3 {
-					// If multiple connections are being used and we're getting timeouts, 
-					// this might indicate the server is blocking multiple connections
-					if d.concurrency > 1 {
+					// If multiple connections are being used and we're getting timeouts,
+					// this might indicate the server is blocking multiple connections -
+					// or, with mirrors configured, just that this one mirror is struggling.
+					if usingMirror {
+						d.mirrorHealth.recordFailure(mirrorURL)
+					} else if d.concurrency > 1 {
 						d.multiConnectionMutex.Lock()
 						d.multiConnectionFailed = true
 						d.multiConnectionMutex.Unlock()
 					}
-					
+
 					if d.reporter != nil {
 						d.reporter.Report(map[string]interface{}{
 							"type":    "http",
@@ -1351,36 +1537,36 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 					}
 					// Close the response body and return error to trigger chunk retry
 					resp.Body.Close()
-					return fmt.Errorf("chunk %d: too many consecutive read timeouts (%d) or no progress for %v", 
+					return fmt.Errorf("chunk %d: too many consecutive read timeouts (%d) or no progress for %v",
 						idx, consecutiveTimeouts, timeSinceProgress)
 				}
-				
+
 				// Log warning but continue - the next read might succeed
 				if d.reporter != nil && consecutiveTimeouts <= 3 {
 					// Only log first few timeouts to avoid spam
 					var m runtime.MemStats
 					runtime.ReadMemStats(&m)
 					d.reporter.Report(map[string]interface{}{
-						"type":    "http",
-						"status":  "warning",
+						"type":   "http",
+						"status": "warning",
 						"message": fmt.Sprintf("Chunk %d read timeout at %d bytes (attempt %d/%d): %v",
 							idx, chunkDownloaded, consecutiveTimeouts, maxConsecutiveTimeouts, err),
 					})
 				}
-				
+
 				// Extend read deadline more aggressively when timeouts occur
 				if tcpConn != nil {
 					newDeadline := time.Now().Add(d.readTimeout * 3)
 					tcpConn.SetReadDeadline(newDeadline)
 				}
-				
+
 				time.Sleep(200 * time.Millisecond) // Brief pause before retrying
 				continue
 			}
-			
+
 			// Non-timeout errors: reset timeout counter and fail
 			consecutiveTimeouts = 0
-			
+
 			// Log error
 			if d.reporter != nil {
 				d.reporter.Report(map[string]interface{}{
@@ -1389,7 +1575,7 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 					"message": fmt.Sprintf("Part %d read error: %v", idx, err),
 				})
 			}
-			
+
 			return fmt.Errorf("chunk %d read error at %d bytes: %w", idx, chunkDownloaded, err)
 		}
 	}
@@ -1405,8 +1591,11 @@ func (d *HTTPDownloader) downloadChunk(idx int, c chunk) error {
 		}
 		return fmt.Errorf("chunk %d incomplete: downloaded %d of %d bytes", idx, chunkDownloaded, expectedChunkSize)
 	}
-	
+
 	// Chunk completed successfully - progress shown in status updates
+	if usingMirror {
+		d.mirrorHealth.recordSuccess(mirrorURL)
+	}
 
 	return nil
 }
@@ -1419,7 +1608,7 @@ func (d *HTTPDownloader) assemble() error {
 			"progress": 1.0,
 		})
 	}
-	
+
 	// Create merged file in temp directory first
 	fileName := filepath.Base(d.outPath)
 	tempMergedPath := filepath.Join(d.tempDir, fileName)
@@ -1431,7 +1620,7 @@ func (d *HTTPDownloader) assemble() error {
 
 	totalChunks := len(d.chunks)
 	totalMerged := int64(0)
-	
+
 	// First, verify all chunk files exist and get their sizes
 	chunkSizes := make([]int64, totalChunks)
 	totalChunkSize := int64(0)
@@ -1450,29 +1639,29 @@ func (d *HTTPDownloader) assemble() error {
 		totalChunkSize += stat.Size()
 		partFile.Close()
 	}
-	
+
 	// Verify total chunk size matches expected
 	expectedChunkSize := int64(0)
 	for _, c := range d.chunks {
 		expectedChunkSize += (c.end - c.start + 1)
 	}
-	
+
 	if totalChunkSize != expectedChunkSize {
 		return fmt.Errorf("chunk size mismatch: expected %d bytes, got %d bytes", expectedChunkSize, totalChunkSize)
 	}
-	
+
 	// Report verification status
 	if d.reporter != nil {
 		d.reporter.Report(map[string]interface{}{
-			"type":              "http",
-			"status":            "merging",
-			"progress":          1.0,
-			"verification":      "verified",
-			"chunk_total_size":  totalChunkSize,
-			"expected_size":     expectedChunkSize,
+			"type":             "http",
+			"status":           "merging",
+			"progress":         1.0,
+			"verification":     "verified",
+			"chunk_total_size": totalChunkSize,
+			"expected_size":    expectedChunkSize,
 		})
 	}
-	
+
 	// Now merge the chunks in order
 	for i, c := range d.chunks {
 		partPath := filepath.Join(d.tempDir, fmt.Sprintf("%s.part.%d.%d", fileName, c.start, c.end))
@@ -1480,7 +1669,7 @@ func (d *HTTPDownloader) assemble() error {
 		if err != nil {
 			return fmt.Errorf("failed to open chunk %d file: %s", i, err)
 		}
-		
+
 		// Verify chunk file size before merging
 		partInfo, err := partFile.Stat()
 		if err != nil {
@@ -1492,7 +1681,7 @@ func (d *HTTPDownloader) assemble() error {
 			partFile.Close()
 			return fmt.Errorf("chunk %d size mismatch: expected %d bytes, got %d bytes", i, expectedSize, partInfo.Size())
 		}
-		
+
 		// Report merging progress
 		if d.reporter != nil {
 			mergeProgress := float64(i+1) / float64(totalChunks)
@@ -1502,13 +1691,13 @@ func (d *HTTPDownloader) assemble() error {
 				"status":         "merging",
 				"progress":       1.0,
 				"merge_progress": mergeProgress,
-				"merge_chunk":     i + 1,
+				"merge_chunk":    i + 1,
 				"merge_total":    totalChunks,
 				"merged_bytes":   bytesWritten,
 				"total_bytes":    totalChunkSize,
 			})
 		}
-		
+
 		copied, err := io.Copy(outFile, partFile)
 		if err != nil {
 			partFile.Close()
@@ -1520,28 +1709,28 @@ func (d *HTTPDownloader) assemble() error {
 		}
 		totalMerged += copied
 		partFile.Close()
-		
+
 		// Remove chunk file after successful merge
 		if err := os.Remove(partPath); err != nil {
 			// Log but don't fail - chunk is already merged
 			fmt.Printf("Warning: failed to remove chunk file %s: %s\n", partPath, err)
 		}
 	}
-	
+
 	// Close the file before moving
 	outFile.Close()
-	
+
 	// Verify final file size
 	outFileInfo, err := os.Stat(tempMergedPath)
 	if err != nil {
 		return fmt.Errorf("failed to stat merged file: %s", err)
 	}
-	
+
 	finalSize := outFileInfo.Size()
 	if finalSize != d.totalSize {
 		return fmt.Errorf("file size mismatch: expected %d bytes, got %d bytes", d.totalSize, finalSize)
 	}
-	
+
 	if totalMerged != d.totalSize {
 		return fmt.Errorf("merged size mismatch: expected %d bytes, merged %d bytes", d.totalSize, totalMerged)
 	}
@@ -1561,7 +1750,7 @@ func (d *HTTPDownloader) assemble() error {
 	if err := os.Rename(tempMergedPath, d.outPath); err != nil {
 		return fmt.Errorf("failed to move merged file to destination: %s", err)
 	}
-	
+
 	// Clean up temp directory and all chunk files
 	os.RemoveAll(d.tempDir)
 
@@ -1580,6 +1769,7 @@ func (d *HTTPDownloader) verifySHA256() error {
 	computed := hex.EncodeToString(hash.Sum(nil))
 
 	if strings.ToLower(computed) != strings.ToLower(d.sha256) {
+		recordPieceHashFailure()
 		return fmt.Errorf("SHA256 mismatch: expected %s, got %s", d.sha256, computed)
 	}
 
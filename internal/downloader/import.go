@@ -0,0 +1,176 @@
+package downloader
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anacrolix/torrent/bencode"
+)
+
+// ImportFormat identifies the resume-file layout Import should expect.
+type ImportFormat string
+
+const (
+	ImportQBittorrentFastresume ImportFormat = "qbittorrent-fastresume"
+	ImportTransmissionResume    ImportFormat = "transmission-resume"
+	ImportLibtorrentResume      ImportFormat = "libtorrent-resume"
+)
+
+// Import parses a bencoded resume file from an existing BitTorrent client and
+// produces one Options per torrent it describes, with DownloadID set to
+// "<save path>/<info-hash>" (just the info-hash when the resume file records
+// no save path), Trackers/BTFileSelect/BTPiecePriority carried over
+// where the format stores them, and a companion "<info-hash>.piece-bitmap"
+// sidecar written next to path, carrying the raw piece-completion bitfield;
+// TorrentDownloader.Download reads it back from its data directory (so the
+// sidecar must be copied or moved there alongside the already-downloaded
+// data) and seeds the same pieces complete, so resume doesn't require
+// re-hashing data the original client had already verified.
+func Import(path string, format ImportFormat) ([]Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resume file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := bencode.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse resume file as bencode: %w", err)
+	}
+
+	switch format {
+	case ImportQBittorrentFastresume:
+		return importFastresume(raw, path, "qBt-savePath", "save_path")
+	case ImportLibtorrentResume:
+		return importFastresume(raw, path, "save_path")
+	case ImportTransmissionResume:
+		return importTransmissionResume(raw, path)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+// importFastresume handles the qBittorrent and plain libtorrent .fastresume
+// layouts, which share the same info-hash/save_path/file-priority/pieces keys
+// and differ mainly in which save-path key is populated.
+func importFastresume(raw map[string]interface{}, path string, savePathKeys ...string) ([]Options, error) {
+	infoHash, err := bencodeInfoHash(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	savePath := ""
+	for _, key := range savePathKeys {
+		if v, ok := raw[key].(string); ok && v != "" {
+			savePath = v
+			break
+		}
+	}
+
+	opts := Options{DownloadID: infoHash}
+	if pieces, ok := raw["pieces"].(string); ok {
+		if err := writePieceBitmapSidecar(path, infoHash, []byte(pieces)); err != nil {
+			return nil, err
+		}
+	}
+	if savePath != "" {
+		opts.DownloadID = filepath.Join(savePath, infoHash)
+	}
+	opts.Trackers = bencodeTrackers(raw)
+	opts.BTFileSelect = bencodeFilePriority(raw)
+	if priority, ok := raw["piece_priority"].(string); ok {
+		opts.BTPiecePriority = []byte(priority)
+	}
+
+	return []Options{opts}, nil
+}
+
+// importTransmissionResume handles transmission's per-torrent .resume files,
+// which nest completion state under "progress" -> "pieces" and the
+// destination path under "destination".
+func importTransmissionResume(raw map[string]interface{}, path string) ([]Options, error) {
+	infoHash, err := bencodeInfoHash(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := Options{DownloadID: infoHash}
+	if dest, ok := raw["destination"].(string); ok && dest != "" {
+		opts.DownloadID = filepath.Join(dest, infoHash)
+	}
+
+	if progress, ok := raw["progress"].(map[string]interface{}); ok {
+		if pieces, ok := progress["pieces"].(string); ok {
+			if err := writePieceBitmapSidecar(path, infoHash, []byte(pieces)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return []Options{opts}, nil
+}
+
+// bencodeInfoHash extracts a 20-byte info-hash (stored under "info-hash" or
+// "hash" depending on the client) and hex-encodes it for use as a DownloadID.
+func bencodeInfoHash(raw map[string]interface{}) (string, error) {
+	for _, key := range []string{"info-hash", "hash"} {
+		if v, ok := raw[key].(string); ok && v != "" {
+			return hex.EncodeToString([]byte(v)), nil
+		}
+	}
+	return "", fmt.Errorf("resume file missing info-hash/hash field")
+}
+
+// writePieceBitmapSidecar persists the raw piece-completion bitfield next to
+// the resume file so the native downloader can seed its own resume state
+// without re-verifying every piece.
+func writePieceBitmapSidecar(resumePath, infoHash string, bitmap []byte) error {
+	sidecarPath := filepath.Join(filepath.Dir(resumePath), infoHash+".piece-bitmap")
+	return os.WriteFile(sidecarPath, bitmap, 0644)
+}
+
+// bencodeTrackers flattens raw["trackers"], which qBittorrent/libtorrent
+// store as a list of tiers (each tier itself a list of URL strings), into a
+// single tracker list for Options.Trackers. Missing or malformed entries are
+// skipped rather than failing the whole import.
+func bencodeTrackers(raw map[string]interface{}) []string {
+	tiers, ok := raw["trackers"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var trackers []string
+	for _, tier := range tiers {
+		urls, ok := tier.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, u := range urls {
+			if url, ok := u.(string); ok && url != "" {
+				trackers = append(trackers, url)
+			}
+		}
+	}
+	return trackers
+}
+
+// bencodeFilePriority turns raw["file_priority"] (one bencode integer per
+// file, 0 meaning "don't download") into a FileSelector naming the
+// non-zero-priority files, so the native downloader carries over which
+// files the original client had already chosen to skip. Returns nil if the
+// field is absent, which selects every file as before.
+func bencodeFilePriority(raw map[string]interface{}) *FileSelector {
+	priorities, ok := raw["file_priority"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var indices []int
+	for i, p := range priorities {
+		priority, ok := p.(int64)
+		if ok && priority == 0 {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return &FileSelector{Indices: indices}
+}
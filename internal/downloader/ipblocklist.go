@@ -0,0 +1,153 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/anacrolix/torrent/iplist"
+)
+
+// loadIPBlocklistSource fetches a blocklist from a file path or URL and
+// parses it, trying the PeerGuardian/eMule ".p2p" format first (what
+// iplist.NewFromReader understands) and falling back to a plain list of
+// CIDR ranges, one per line. A source ending in ".gz" is gzip-decoded first,
+// the same as the PeerGuardian lists most blocklist hosts distribute.
+func loadIPBlocklistSource(source string) (iplist.Ranger, error) {
+	data, err := readBlocklistSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(strings.ToLower(source), ".gz") {
+		data, err = gunzip(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress IP blocklist: %w", err)
+		}
+	}
+
+	if list, err := iplist.NewFromReader(bytes.NewReader(data)); err == nil {
+		return list, nil
+	}
+
+	ranges, err := parseCIDRBlocklist(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no valid ranges found in blocklist (expected .p2p or CIDR format)")
+	}
+	return iplist.New(ranges), nil
+}
+
+// gunzip decompresses a gzip member in full; blocklists are small enough
+// (at most a few MB of text) that reading it all into memory is fine.
+func gunzip(data []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+func readBlocklistSource(source string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch IP blocklist: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch IP blocklist: status %d", resp.StatusCode)
+		}
+		return io.ReadAll(resp.Body)
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IP blocklist: %w", err)
+	}
+	return data, nil
+}
+
+// parseCIDRBlocklist reads one CIDR range per line (blank lines and "#"
+// comments ignored) and expands each to the first/last IP in the range.
+func parseCIDRBlocklist(r io.Reader) ([]iplist.Range, error) {
+	var ranges []iplist.Range
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ip, ipNet, err := net.ParseCIDR(line)
+		if err != nil {
+			continue // not a CIDR line - ignore rather than fail the whole list
+		}
+		first := ip.Mask(ipNet.Mask)
+		last := make(net.IP, len(first))
+		for i := range first {
+			last[i] = first[i] | ^ipNet.Mask[i]
+		}
+		ranges = append(ranges, iplist.Range{First: first, Last: last, Description: line})
+	}
+	return ranges, scanner.Err()
+}
+
+// ipBlocklist wraps an iplist.Ranger so it can be swapped out atomically (for
+// periodic refresh of a URL-sourced blocklist) while counting how many
+// Lookup calls actually matched a blocked range, for status reporting.
+type ipBlocklist struct {
+	ranger  atomic.Value // iplist.Ranger
+	blocked int64
+}
+
+func newIPBlocklist(r iplist.Ranger) *ipBlocklist {
+	b := &ipBlocklist{}
+	b.ranger.Store(r)
+	return b
+}
+
+func (b *ipBlocklist) set(r iplist.Ranger) {
+	b.ranger.Store(r)
+}
+
+// Lookup implements iplist.Ranger, so an *ipBlocklist can be assigned
+// directly to torrent.ClientConfig.IPBlocklist.
+func (b *ipBlocklist) Lookup(ip net.IP) (r iplist.Range, ok bool) {
+	ranger, _ := b.ranger.Load().(iplist.Ranger)
+	if ranger == nil {
+		return iplist.Range{}, false
+	}
+	r, ok = ranger.Lookup(ip)
+	if ok {
+		atomic.AddInt64(&b.blocked, 1)
+		recordBlockedPeer()
+	}
+	return r, ok
+}
+
+// BlockedCount returns how many peer connections have been rejected by the
+// blocklist so far.
+func (b *ipBlocklist) BlockedCount() int64 {
+	return atomic.LoadInt64(&b.blocked)
+}
+
+// NumRanges implements iplist.Ranger by delegating to the currently-stored
+// ranger, so an *ipBlocklist satisfies the interface torrent.ClientConfig.IPBlocklist
+// expects even as the underlying ranger is swapped out by set.
+func (b *ipBlocklist) NumRanges() int {
+	ranger, _ := b.ranger.Load().(iplist.Ranger)
+	if ranger == nil {
+		return 0
+	}
+	return ranger.NumRanges()
+}
@@ -0,0 +1,148 @@
+package downloader
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Package-level expvar counters shared by every HTTPDownloader and
+// TorrentDownloader in the process. They're cheap to update unconditionally,
+// so call sites don't need to check whether Options.MetricsAddr is set -
+// only StartMetricsServer gates whether anything is actually listening.
+var (
+	metricsBytesRead        = expvar.NewInt("clidm_bytes_read_total")
+	metricsBytesWritten     = expvar.NewInt("clidm_bytes_written_total")
+	metricsActiveConns      = expvar.NewInt("clidm_active_connections")
+	metricsActivePeers      = expvar.NewInt("clidm_active_peers")
+	metricsConnectedSeeders = expvar.NewInt("clidm_connected_seeders")
+	metricsPieceHashFails   = expvar.NewInt("clidm_piece_hash_failures_total")
+	metricsRetries          = expvar.NewInt("clidm_retries_total")
+	metricsRateLimiterWaits = expvar.NewInt("clidm_rate_limiter_waits_total")
+	metricsBlockedPeers     = expvar.NewInt("clidm_blocked_peers_total")
+	metricsDownloadProgress = expvar.NewMap("clidm_download_progress")
+
+	downloadProgressMu    sync.Mutex
+	downloadProgressGauge = map[string]*expvar.Float{}
+)
+
+func recordBytesRead(n int64)    { metricsBytesRead.Add(n) }
+func recordBytesWritten(n int64) { metricsBytesWritten.Add(n) }
+func recordRetry()               { metricsRetries.Add(1) }
+func recordRateLimiterWait()     { metricsRateLimiterWaits.Add(1) }
+func recordPieceHashFailure()    { metricsPieceHashFails.Add(1) }
+func recordBlockedPeer()         { metricsBlockedPeers.Add(1) }
+
+func setActiveConns(n int64)      { metricsActiveConns.Set(n) }
+func setActivePeers(n int64)      { metricsActivePeers.Set(n) }
+func setConnectedSeeders(n int64) { metricsConnectedSeeders.Set(n) }
+
+// setDownloadProgress records the fractional (0-1) progress of the download
+// identified by downloadID. Downloads that don't set Options.DownloadID are
+// not tracked individually, since there'd be no stable key to report under.
+func setDownloadProgress(downloadID string, progress float64) {
+	if downloadID == "" {
+		return
+	}
+	downloadProgressMu.Lock()
+	g, ok := downloadProgressGauge[downloadID]
+	if !ok {
+		g = new(expvar.Float)
+		downloadProgressGauge[downloadID] = g
+		metricsDownloadProgress.Set(downloadID, g)
+	}
+	downloadProgressMu.Unlock()
+	g.Set(progress)
+}
+
+// StartMetricsServer starts an HTTP server exposing /debug/vars (expvar) and
+// /metrics (Prometheus text format) on addr, covering both the HTTP and
+// torrent downloaders' counters above as well as anything else registered
+// with the standard expvar package - including the anacrolix/torrent
+// client's own built-in vars, which register themselves on import. It
+// returns nil, nil if addr is empty, so callers can unconditionally defer a
+// shutdown. The caller is responsible for calling Shutdown when done (e.g.
+// on ctx.Done()).
+func StartMetricsServer(addr string) (*http.Server, error) {
+	if addr == "" {
+		return nil, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/metrics", servePrometheusMetrics)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start metrics server: %w", err)
+	}
+	go srv.Serve(ln)
+	return srv, nil
+}
+
+// servePrometheusMetrics renders every var registered with expvar (ours and
+// anacrolix/torrent's) as Prometheus text exposition format. There's no
+// Prometheus client dependency in this module, so this covers only the
+// numeric vars (ints, floats, and maps of them) that actually show up here;
+// anything else is skipped rather than guessed at.
+func servePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	type sample struct {
+		name  string
+		value float64
+	}
+	var samples []sample
+
+	expvar.Do(func(kv expvar.KeyValue) {
+		base := sanitizeMetricName(kv.Key)
+		switch v := kv.Value.(type) {
+		case *expvar.Int:
+			samples = append(samples, sample{base, float64(v.Value())})
+		case *expvar.Float:
+			samples = append(samples, sample{base, v.Value()})
+		case *expvar.Map:
+			v.Do(func(inner expvar.KeyValue) {
+				switch iv := inner.Value.(type) {
+				case *expvar.Int:
+					samples = append(samples, sample{fmt.Sprintf("%s{id=%q}", base, inner.Key), float64(iv.Value())})
+				case *expvar.Float:
+					samples = append(samples, sample{fmt.Sprintf("%s{id=%q}", base, inner.Key), iv.Value()})
+				}
+			})
+		}
+	})
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].name < samples[j].name })
+	for _, s := range samples {
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s %v\n", strings.SplitN(s.name, "{", 2)[0], s.name, s.value)
+	}
+}
+
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// ShutdownMetricsServer gives srv up to 5 seconds to finish in-flight scrapes
+// before closing. It's a no-op if srv is nil, so callers can call it
+// unconditionally on shutdown.
+func ShutdownMetricsServer(srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+}
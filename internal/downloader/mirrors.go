@@ -0,0 +1,95 @@
+package downloader
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// mirrorHealth tracks per-mirror failure counts so one bad mirror in a
+// MirrorSet gets routed around instead of collapsing concurrency for the
+// whole download, the way the old global d.multiConnectionFailed flag did.
+type mirrorHealth struct {
+	mu          sync.Mutex
+	failures    map[string]int
+	maxFailures int
+}
+
+func newMirrorHealth() *mirrorHealth {
+	return &mirrorHealth{failures: make(map[string]int), maxFailures: 3}
+}
+
+func (h *mirrorHealth) recordFailure(mirror string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[mirror]++
+}
+
+func (h *mirrorHealth) recordSuccess(mirror string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[mirror] = 0
+}
+
+func (h *mirrorHealth) isHealthy(mirror string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failures[mirror] < h.maxFailures
+}
+
+// chunkKey is the HRW hashing key for a chunk: its byte range, so the same
+// chunk always hashes to the same rendezvous order across retries (helpful
+// for mirror-side caching).
+func chunkKey(c chunk) string {
+	return fmt.Sprintf("%d-%d", c.start, c.end)
+}
+
+// hrwRank orders mirrors for key by rendezvous/HRW hashing: score(key, m) =
+// hash(key || m), optionally multiplied by m's weight, highest score first.
+// The same key always produces the same order for a fixed mirror set, so
+// chunks land on the same mirror across retries while load still spreads
+// across mirrors overall, skewed toward higher-weighted mirrors.
+func hrwRank(key string, mirrors []string, weights map[string]float64) []string {
+	type scored struct {
+		mirror string
+		score  float64
+	}
+	ranked := make([]scored, 0, len(mirrors))
+	for _, m := range mirrors {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte("|"))
+		h.Write([]byte(m))
+		score := float64(h.Sum64())
+		if w, ok := weights[m]; ok && w > 0 {
+			score *= w
+		}
+		ranked = append(ranked, scored{mirror: m, score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	out := make([]string, len(ranked))
+	for i, s := range ranked {
+		out[i] = s.mirror
+	}
+	return out
+}
+
+// pickMirrorURL returns the first healthy mirror for c in HRW order. It
+// returns d.sourceURL when no MirrorSet is configured, and the top-ranked
+// mirror when every mirror looks unhealthy.
+func (d *HTTPDownloader) pickMirrorURL(c chunk) string {
+	if len(d.mirrors) == 0 {
+		return d.sourceURL
+	}
+
+	for _, m := range hrwRank(chunkKey(c), d.mirrors, d.mirrorWeights) {
+		if d.mirrorHealth.isHealthy(m) {
+			return m
+		}
+	}
+	// Every mirror is currently marked unhealthy - try the top-ranked one
+	// anyway rather than giving up; it may have recovered.
+	return hrwRank(chunkKey(c), d.mirrors, d.mirrorWeights)[0]
+}
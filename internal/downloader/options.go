@@ -1,24 +1,80 @@
 package downloader
 
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
 // StatusReporter interface for reporting download status
 type StatusReporter interface {
 	Report(status map[string]interface{})
 }
 
+// TransportConfig tunes the http.Transport NewHTTPDownloader builds and
+// reuses across all of a download's chunk workers. A nil Options.Transport
+// keeps the existing defaults (http.ProxyFromEnvironment, no custom dialer).
+type TransportConfig struct {
+	DialTimeout           time.Duration
+	DialKeepAlive         time.Duration
+	DisableNoDelay        bool // disables TCP_NODELAY, re-enabling Nagle's algorithm
+	MaxIdleConnsPerHost   int
+	IdleConnTimeout       time.Duration
+	ResponseHeaderTimeout time.Duration
+	ForceHTTP2            bool
+	TLSConfig             *tls.Config
+}
+
 // Options contains all download options
 type Options struct {
-	Connections    int
-	ChunkSize      int64
-	RateLimit      int64
-	Proxy          string
-	Retries        int
-	ConnectTimeout int
-	ReadTimeout    int
-	SHA256         string
-	BTUploadLimit  int64
-	BTSequential   bool
-	BTKeepSeeding  bool
-	Quiet          bool
-	StatusReporter StatusReporter
-	DownloadID     string // For state persistence
+	Connections        int
+	ChunkSize          int64
+	RateLimit          int64
+	Proxy              string
+	Retries            int
+	ConnectTimeout     int
+	ReadTimeout        int
+	SHA256             string
+	BTUploadLimit      int64
+	BTSequential       bool
+	BTKeepSeeding      bool
+	BTPort             int
+	WebSeeds           []string      // BEP 19 webseed URLs to use as an HTTP fallback/race against the swarm
+	Trackers           []string      // additional tracker URLs added to the swarm alongside any the torrent/magnet already advertises
+	BTPiecePriority    []byte        // one priority byte per piece (0 skips it, anything else downloads it at Normal priority); nil leaves every piece at its default priority
+	BTBlocklist        string        // file path or URL of an IP blocklist (PeerGuardian/eMule .p2p format, or plain CIDR list)
+	BTBlocklistRefresh time.Duration // re-fetch interval for a URL-sourced BTBlocklist; 0 disables periodic refresh
+	BTDisableUTP       bool
+	BTDisableTCP       bool
+	BTDisableIPv6      bool
+	BTStream           bool          // open Options output as a streaming read instead of t.DownloadAll(), prioritizing pieces near the read head
+	BTStreamFile       string        // path (or suffix) of the file to stream in a multi-file torrent; empty picks the largest file
+	BTReadahead        int64         // bytes ahead of the read head to prioritize as PiecePriorityReadahead; 0 uses pieceLength*4
+	BTStreamOnly       bool          // pieces outside the read/readahead window get PiecePriorityNone instead of Normal, so nothing downloads except what streaming needs
+	BTStorage          string        // "file" (default), "mmap", "piece-file", "piece", or "memory" - selects cfg.DefaultStorage
+	BTEmitPieceBitmap  bool          // include a base64 per-piece completion bitfield in status reports, in addition to the run-length summary
+	BTFileSelect       *FileSelector // nil downloads every file in a multi-file torrent, as before
+	Backend            Backend       // nil selects the native engine; set to delegate to aria2/qBittorrent
+	AriaRPC            string        // aria2 JSON-RPC endpoint, e.g. "http://localhost:6800/jsonrpc"
+	AriaSecret         string
+	QbitURL            string
+	QbitUsername       string
+	QbitPassword       string
+	Quiet              bool
+	StatusReporter     StatusReporter
+	DownloadID         string             // For state persistence
+	Context            context.Context    // Cancels in-flight requests when done
+	RetryPolicy        RetryPolicy        // nil uses NewDefaultRetryPolicy with the downloader's 10-failure connection budget (Retries is not consulted)
+	Transport          *TransportConfig   // nil keeps NewHTTPDownloader's default transport
+	SingleFileMode     bool               // write chunks directly into outPath via WriteAt instead of merging temp parts
+	Mirrors            []string           // equivalent URLs to dispatch chunks across via HRW hashing; when set, chunk requests go only to these URLs, so the primary source gets chunks only if it is listed here too
+	MirrorWeights      map[string]float64 // optional per-mirror HRW weight (higher = more traffic); unlisted mirrors default to 1
+	BufferPool         *BufferPool        // nil allocates a fresh read buffer per chunk, as before; share one pool across a batch run to cut GC pressure
+	ReadBufferSize     int                // size of chunk read buffers when BufferPool is nil; 0 uses the 64KiB default
+	FaultInjector      FaultInjector      // nil disables fault injection entirely, at no runtime cost
+	MetricsAddr        string             // if set, StartMetricsServer(MetricsAddr) exposes /debug/vars and /metrics; empty disables the endpoint
+	DownloadLimiter    *rate.Limiter      // nil builds a private limiter from RateLimit, as before; set by Scheduler so a batch of jobs shares one download-rate budget across all its jobs
+	UploadLimiter      *rate.Limiter      // nil builds a private limiter from BTUploadLimit, as before; set by Scheduler so a batch of jobs shares one upload-rate budget across all its jobs
 }
@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"encoding/base64"
+
+	"github.com/anacrolix/torrent"
+)
+
+// pieceStateRun is the run-length encoded replacement for a per-piece bool
+// array in status reports: one entry per run of consecutive pieces sharing
+// the same state, instead of one entry per piece.
+type pieceStateRun struct {
+	Length   int  `json:"length"`
+	Complete bool `json:"complete"`
+	Partial  bool `json:"partial"`
+	Checking bool `json:"checking"`
+	Priority int  `json:"priority"`
+}
+
+// summarizePieceStates run-length encodes t's current piece states via
+// t.PieceStateRuns(), which is orders of magnitude smaller than a per-piece
+// bool array for a torrent with tens of thousands of pieces, while - unlike
+// the bool array - keeping the partial/checking information each run also
+// carries.
+func summarizePieceStates(t *torrent.Torrent) (runs []pieceStateRun, completed, partial, checking int) {
+	for _, r := range t.PieceStateRuns() {
+		runs = append(runs, pieceStateRun{
+			Length:   r.Length,
+			Complete: r.Complete,
+			Partial:  r.Partial,
+			Checking: r.Checking,
+			Priority: int(r.Priority),
+		})
+		if r.Complete {
+			completed += r.Length
+		}
+		if r.Partial {
+			partial += r.Length
+		}
+		if r.Checking {
+			checking += r.Length
+		}
+	}
+	return runs, completed, partial, checking
+}
+
+// pieceBitmap packs t's per-piece completion state into a bitfield and
+// base64-encodes it, for consumers that need per-piece detail the
+// run-length summary doesn't carry (e.g. a piece-availability heatmap).
+// Only computed when Options.BTEmitPieceBitmap is set - it costs one
+// NumPieces()-sized pass, unlike the free-as-a-byproduct run-length summary.
+func pieceBitmap(t *torrent.Torrent) string {
+	n := t.NumPieces()
+	buf := make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		if t.PieceState(i).Complete {
+			buf[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,184 @@
+package downloader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Job is a single source to fetch as part of a Scheduler.Run call.
+type Job struct {
+	SourceURL string
+	OutPath   string
+	SHA256    string // optional; verified the same way Options.SHA256 is for a single download
+	Options   Options
+}
+
+// JobResult is the outcome of running one Job.
+type JobResult struct {
+	Job Job
+	Err error
+}
+
+// Scheduler runs many Jobs concurrently, capping how many run at once and
+// sharing a single RateLimit/BTUploadLimit token bucket across every job so
+// the aggregate throughput never exceeds the user's cap regardless of how
+// many jobs are in flight.
+type Scheduler struct {
+	Parallel      int
+	FailFast      bool
+	RateLimit     int64 // shared across all jobs; 0 disables download throttling
+	BTUploadLimit int64 // shared across all jobs; 0 disables upload throttling
+	Reporter      StatusReporter
+}
+
+// NewScheduler returns a Scheduler that runs up to parallel jobs at once.
+// When failFast is true, the first job to error cancels every job still in
+// flight; otherwise every job runs to completion regardless of earlier
+// failures.
+func NewScheduler(parallel int, failFast bool) *Scheduler {
+	if parallel < 1 {
+		parallel = 1
+	}
+	return &Scheduler{Parallel: parallel, FailFast: failFast}
+}
+
+// Run fetches every job, at most s.Parallel at a time, and returns one
+// JobResult per job in the same order they were given. When s.RateLimit/
+// s.BTUploadLimit is non-zero, a job's own Options.RateLimit/BTUploadLimit
+// is ignored in favor of the shared budget; when it is 0, no shared limiter
+// is set and a job's own limit (if any) still applies to that job alone.
+func (s *Scheduler) Run(ctx context.Context, jobs []Job) []JobResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var downloadLimiter, uploadLimiter *rate.Limiter
+	if s.RateLimit > 0 {
+		downloadLimiter = rate.NewLimiter(rate.Limit(s.RateLimit), int(s.RateLimit))
+	}
+	if s.BTUploadLimit > 0 {
+		uploadLimiter = rate.NewLimiter(rate.Limit(s.BTUploadLimit), int(s.BTUploadLimit))
+	}
+
+	// One BufferPool shared across every job in the batch, same as
+	// downloadLimiter/uploadLimiter, so a batch run doesn't allocate a fresh
+	// read buffer per chunk per job. Sized off the first job's
+	// ReadBufferSize, since a batch's jobs are normally built from one
+	// shared base Options; a job that already set its own BufferPool is left
+	// alone.
+	bufSize := 0
+	if len(jobs) > 0 {
+		bufSize = jobs[0].Options.ReadBufferSize
+	}
+	if bufSize <= 0 {
+		bufSize = 65536
+	}
+	bufferPool := NewBufferPool(bufSize, false, 0)
+	defer bufferPool.Close()
+
+	results := make([]JobResult, len(jobs))
+	sem := make(chan struct{}, s.Parallel)
+	var wg sync.WaitGroup
+	sfGroup := NewSingleflightGroup()
+
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, j Job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				results[idx] = JobResult{Job: j, Err: ctx.Err()}
+				return
+			}
+
+			opts := j.Options
+			opts.Context = ctx
+			opts.SHA256 = j.SHA256
+			opts.DownloadLimiter = downloadLimiter
+			opts.UploadLimiter = uploadLimiter
+			if opts.BufferPool == nil {
+				opts.BufferPool = bufferPool
+			}
+			opts.StatusReporter = &schedulerReporter{inner: opts.StatusReporter, sched: s, job: j}
+
+			// A batch file can list the same (source, outPath, sha256) more
+			// than once; sfGroup coalesces those into one actual download
+			// instead of racing two sets of connections against the source.
+			err := sfGroup.Download(j.SourceURL, j.OutPath, opts)
+
+			results[idx] = JobResult{Job: j, Err: err}
+			if err != nil && s.FailFast {
+				cancel()
+			}
+		}(i, job)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// schedulerReporter fans a single job's status events out to both that
+// job's own StatusReporter (if any) and the Scheduler's aggregated one,
+// tagging the latter with which job the event came from.
+type schedulerReporter struct {
+	inner StatusReporter
+	sched *Scheduler
+	job   Job
+}
+
+func (r *schedulerReporter) Report(status map[string]interface{}) {
+	if r.inner != nil {
+		r.inner.Report(status)
+	}
+	if r.sched.Reporter == nil {
+		return
+	}
+	tagged := make(map[string]interface{}, len(status)+2)
+	for k, v := range status {
+		tagged[k] = v
+	}
+	tagged["sourceURL"] = r.job.SourceURL
+	tagged["outPath"] = r.job.OutPath
+	r.sched.Reporter.Report(tagged)
+}
+
+// ParseBatchFile reads one job per non-empty, non-comment ("#"-prefixed)
+// line from r: "<source>", "<source> <outPath>", or
+// "<source> <outPath> <sha256>", whitespace-separated. It's the format
+// accepted by -batch in cmd/clidm.
+func ParseBatchFile(r io.Reader) ([]Job, error) {
+	var jobs []Job
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		job := Job{SourceURL: fields[0]}
+		if len(fields) > 1 {
+			job.OutPath = fields[1]
+		}
+		if len(fields) > 2 {
+			job.SHA256 = fields[2]
+		}
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("batch file line %d: too many fields: %q", lineNo, line)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,74 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSchedulerFailFastCancelsInFlightJob covers Run's FailFast path: once
+// one job errors, every other job still in flight should actually abort
+// (via the shared ctx HTTPDownloader now checks) instead of running to
+// completion after the batch's own wg.Wait returns.
+func TestSchedulerFailFastCancelsInFlightJob(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	blocked := make(chan struct{})
+	var blockedOnce sync.Once
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// probe()'s HEAD check just needs a size; answer it without
+			// blocking so the HEAD request's connection doesn't linger.
+			w.Header().Set("Content-Length", "1")
+			return
+		}
+		w.Header().Set("Content-Length", "1")
+		w.WriteHeader(http.StatusOK)
+		blockedOnce.Do(func() { close(blocked) })
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	jobs := []Job{
+		{SourceURL: failing.URL, OutPath: filepath.Join(t.TempDir(), "failing.bin")},
+		{SourceURL: slow.URL, OutPath: filepath.Join(t.TempDir(), "slow.bin")},
+	}
+
+	sched := NewScheduler(2, true)
+
+	done := make(chan []JobResult, 1)
+	go func() {
+		done <- sched.Run(context.Background(), jobs)
+	}()
+
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow job never reached the server")
+	}
+
+	var results []JobResult
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the failing job should have cancelled the slow one")
+	}
+
+	var slowResult JobResult
+	for _, r := range results {
+		if r.Job.SourceURL == slow.URL {
+			slowResult = r
+		}
+	}
+	if !errors.Is(slowResult.Err, context.Canceled) {
+		t.Fatalf("expected the slow job to be cancelled once the failing job triggered FailFast, got %v", slowResult.Err)
+	}
+}
@@ -0,0 +1,222 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// singleFileState is the on-disk sidecar tracking which chunks have landed
+// in outPath, so a SingleFileMode download can resume without re-reading the
+// whole file (there's no per-chunk part file to stat once everything writes
+// into one pre-allocated file).
+type singleFileState struct {
+	TotalSize int64  `json:"totalSize"`
+	Completed []bool `json:"completed"`
+}
+
+func (d *HTTPDownloader) statePath() string {
+	return d.outPath + ".accelara-state"
+}
+
+// loadOrInitState reads outPath's sidecar if it matches the current probe
+// result, or starts a fresh all-incomplete state otherwise (e.g. first run,
+// or the source's size changed since the last attempt).
+func (d *HTTPDownloader) loadOrInitState() *singleFileState {
+	if data, err := os.ReadFile(d.statePath()); err == nil {
+		var st singleFileState
+		if json.Unmarshal(data, &st) == nil && st.TotalSize == d.totalSize && len(st.Completed) == len(d.chunks) {
+			return &st
+		}
+	}
+	return &singleFileState{TotalSize: d.totalSize, Completed: make([]bool, len(d.chunks))}
+}
+
+func (d *HTTPDownloader) saveState(st *singleFileState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.statePath(), data, 0644)
+}
+
+// downloadDirect pre-allocates outPath to its final size and has every chunk
+// worker write directly into it with WriteAt at the chunk's absolute offset,
+// skipping the temp-part-files-then-merge pass downloadSegmented/assemble
+// use. Resume consults the .accelara-state sidecar for which chunks already
+// landed, since individual ranges inside one file can't be stat'd.
+func (d *HTTPDownloader) downloadDirect() error {
+	file, err := os.OpenFile(d.outPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open destination file: %w", err)
+	}
+	defer file.Close()
+
+	if err := file.Truncate(d.totalSize); err != nil {
+		return fmt.Errorf("failed to pre-allocate destination file: %w", err)
+	}
+
+	state := d.loadOrInitState()
+	var stateMu sync.Mutex
+
+	var resumed int64
+	for i, c := range d.chunks {
+		if state.Completed[i] {
+			resumed += c.end - c.start + 1
+		}
+	}
+	d.downloadedMutex.Lock()
+	d.downloaded = resumed
+	d.downloadedMutex.Unlock()
+
+	sem := make(chan struct{}, d.concurrency)
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var firstErr error
+
+	for i, c := range d.chunks {
+		if state.Completed[i] {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, ch chunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := d.downloadChunkDirect(file, idx, ch); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMu.Unlock()
+				return
+			}
+
+			stateMu.Lock()
+			state.Completed[idx] = true
+			d.saveState(state)
+			stateMu.Unlock()
+		}(i, c)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	d.downloadedMutex.Lock()
+	d.downloaded = d.totalSize
+	d.downloadedMutex.Unlock()
+
+	if d.reporter != nil {
+		d.reporter.Report(map[string]interface{}{
+			"type":          "http",
+			"status":        "verifying",
+			"progress":      1.0,
+			"verify_status": "size_verified",
+		})
+	}
+
+	if d.sha256 != "" {
+		if err := d.verifySHA256(); err != nil {
+			return err
+		}
+		if d.reporter != nil {
+			d.reporter.Report(map[string]interface{}{
+				"type":          "http",
+				"status":        "verifying",
+				"progress":      1.0,
+				"verify_status": "checksum_verified",
+			})
+		}
+	}
+
+	os.Remove(d.statePath())
+
+	if d.reporter != nil {
+		d.reporter.Report(map[string]interface{}{
+			"type":       "http",
+			"status":     "completed",
+			"progress":   1.0,
+			"downloaded": d.totalSize,
+			"total":      d.totalSize,
+			"verified":   true,
+		})
+	}
+
+	return nil
+}
+
+// downloadChunkDirect fetches c's range and writes it straight into file at
+// c.start-relative absolute offsets, without any of downloadChunk's
+// part-file resume bookkeeping - resume for SingleFileMode happens at the
+// whole-chunk granularity recorded in singleFileState instead.
+func (d *HTTPDownloader) downloadChunkDirect(file *os.File, idx int, c chunk) error {
+	req, _ := http.NewRequest("GET", d.sourceURL, nil)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start, c.end))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if isConnectionError(err) {
+			return d.handleConnectionFailure(err, nil)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		statusErr := fmt.Errorf("chunk %d: unexpected status %d", idx, resp.StatusCode)
+		return d.handleConnectionFailure(statusErr, resp)
+	}
+
+	buf := d.getBuffer()
+	defer d.putBuffer(buf)
+	offset := c.start
+	lastUpdate := time.Now()
+
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := file.WriteAt(buf[:n], offset); err != nil {
+				return fmt.Errorf("chunk %d write error at offset %d: %w", idx, offset, err)
+			}
+			offset += int64(n)
+
+			d.downloadedMutex.Lock()
+			d.downloaded += int64(n)
+			downloaded := d.downloaded
+			d.downloadedMutex.Unlock()
+
+			if d.reporter != nil && time.Since(lastUpdate) > 200*time.Millisecond {
+				d.reporter.Report(map[string]interface{}{
+					"type":       "http",
+					"status":     "downloading",
+					"progress":   float64(downloaded) / float64(d.totalSize),
+					"downloaded": downloaded,
+					"total":      d.totalSize,
+				})
+				lastUpdate = time.Now()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if isConnectionError(readErr) {
+				return d.handleConnectionFailure(readErr, nil)
+			}
+			return fmt.Errorf("chunk %d read error at offset %d: %w", idx, offset, readErr)
+		}
+	}
+
+	if offset != c.end+1 {
+		return fmt.Errorf("chunk %d incomplete: wrote up to offset %d, expected %d", idx, offset, c.end+1)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package downloader
+
+import (
+	"sync"
+
+	"github.com/accelara/clidm/internal/utils"
+)
+
+// singleflightKey identifies a download for coalescing purposes. Two
+// requests for the same source written to the same path with the same
+// expected checksum are considered the same logical download.
+type singleflightKey struct {
+	sourceURL string
+	outPath   string
+	sha256    string
+}
+
+// SingleflightGroup coalesces concurrent requests for the same
+// (sourceURL, outPath, sha256) into a single underlying download, so that
+// e.g. two API callers racing to fetch the same file don't open two sets of
+// connections against the source. Every caller's StatusReporter gets every
+// status event the one download actually performed emits from the moment
+// that caller joins; events sent before a later caller joined are not
+// replayed to it.
+type SingleflightGroup struct {
+	mu    sync.Mutex
+	calls map[singleflightKey]*sfCall
+}
+
+type sfCall struct {
+	wg        sync.WaitGroup
+	err       error
+	mu        sync.Mutex
+	reporters []StatusReporter
+}
+
+// NewSingleflightGroup returns an empty SingleflightGroup.
+func NewSingleflightGroup() *SingleflightGroup {
+	return &SingleflightGroup{calls: make(map[singleflightKey]*sfCall)}
+}
+
+// Download runs sourceURL's download, or, if one is already in flight for the
+// same key, waits for it and returns its result instead of starting a second
+// one. opts.StatusReporter, if set, is added to the in-flight call's fan-out
+// list either way.
+func (g *SingleflightGroup) Download(sourceURL, outPath string, opts Options) error {
+	key := singleflightKey{sourceURL: sourceURL, outPath: outPath, sha256: opts.SHA256}
+
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		if opts.StatusReporter != nil {
+			call.mu.Lock()
+			call.reporters = append(call.reporters, opts.StatusReporter)
+			call.mu.Unlock()
+		}
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.err
+	}
+
+	call := &sfCall{}
+	call.wg.Add(1)
+	if opts.StatusReporter != nil {
+		call.reporters = append(call.reporters, opts.StatusReporter)
+	}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	opts.StatusReporter = &sfReporter{call: call}
+
+	var err error
+	if utils.IsTorrentLike(sourceURL) {
+		err = NewTorrentDownloader(sourceURL, outPath, opts).Download()
+	} else {
+		err = NewHTTPDownloader(sourceURL, outPath, opts).Download()
+	}
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	call.err = err
+	call.wg.Done()
+	return err
+}
+
+// sfReporter fans a single in-flight download's status events out to every
+// waiter that joined it.
+type sfReporter struct {
+	call *sfCall
+}
+
+func (r *sfReporter) Report(status map[string]interface{}) {
+	r.call.mu.Lock()
+	reporters := append([]StatusReporter(nil), r.call.reporters...)
+	r.call.mu.Unlock()
+
+	for _, rep := range reporters {
+		rep.Report(status)
+	}
+}
@@ -1,28 +1,53 @@
 package downloader
 
 import (
+	"context"
+	"crypto/sha1"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/anacrolix/torrent"
 	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
 	"golang.org/x/time/rate"
 )
 
 type TorrentDownloader struct {
-	source        string
-	outPath       string
-	uploadLimit   int64
-	downloadLimit int64
-	sequential    bool
-	keepSeeding   bool
-	quiet         bool
-	reporter      StatusReporter
-	downloadID    string // For state persistence
-	
+	source          string
+	outPath         string
+	uploadLimit     int64
+	downloadLimit   int64
+	uploadLimiter   *rate.Limiter // shared across a Scheduler's jobs when set; built from uploadLimit otherwise
+	downloadLimiter *rate.Limiter // shared across a Scheduler's jobs when set; built from downloadLimit otherwise
+	sequential      bool
+	keepSeeding     bool
+	webSeeds        []string      // BEP 19 webseed URLs, merged with any advertised in the torrent's metainfo
+	trackers        []string      // additional tracker URLs, added to the swarm alongside any the torrent/magnet already advertises
+	piecePriority   []byte        // one priority byte per piece (0 skips it); nil leaves every piece at its default priority
+	blocklistSrc    string        // file path or URL of an IP blocklist
+	blocklistTTL    time.Duration // re-fetch interval when blocklistSrc is a URL
+	disableUTP      bool
+	disableTCP      bool
+	disableIPv6     bool
+	blocklist       *ipBlocklist // non-nil once a blocklist has been loaded, for status reporting
+	stream          bool
+	streamFile      string
+	readahead       int64
+	streamOnly      bool
+	storageKind     string
+	emitPieceBitmap bool
+	fileSelect      *FileSelector // nil downloads every file, as before
+	quiet           bool
+	reporter        StatusReporter
+	downloadID      string          // For state persistence
+	ctx             context.Context // cancelling this stops Download's run loop and drops the torrent
+
 	// For accurate speed calculation
 	lastBytesRead    int64
 	lastBytesWritten int64
@@ -32,19 +57,41 @@ type TorrentDownloader struct {
 
 func NewTorrentDownloader(source, outPath string, opts Options) *TorrentDownloader {
 	return &TorrentDownloader{
-		source:        source,
-		outPath:       outPath,
-		uploadLimit:   opts.BTUploadLimit,
-		downloadLimit: opts.RateLimit,
-		sequential:    opts.BTSequential,
-		keepSeeding:   opts.BTKeepSeeding,
-		quiet:         opts.Quiet,
-		reporter:      opts.StatusReporter,
-		downloadID:    opts.DownloadID,
+		source:          source,
+		outPath:         outPath,
+		uploadLimit:     opts.BTUploadLimit,
+		downloadLimit:   opts.RateLimit,
+		uploadLimiter:   opts.UploadLimiter,
+		downloadLimiter: opts.DownloadLimiter,
+		sequential:      opts.BTSequential,
+		keepSeeding:     opts.BTKeepSeeding,
+		webSeeds:        opts.WebSeeds,
+		trackers:        opts.Trackers,
+		piecePriority:   opts.BTPiecePriority,
+		blocklistSrc:    opts.BTBlocklist,
+		blocklistTTL:    opts.BTBlocklistRefresh,
+		disableUTP:      opts.BTDisableUTP,
+		disableTCP:      opts.BTDisableTCP,
+		disableIPv6:     opts.BTDisableIPv6,
+		stream:          opts.BTStream,
+		streamFile:      opts.BTStreamFile,
+		readahead:       opts.BTReadahead,
+		streamOnly:      opts.BTStreamOnly,
+		storageKind:     opts.BTStorage,
+		emitPieceBitmap: opts.BTEmitPieceBitmap,
+		fileSelect:      opts.BTFileSelect,
+		quiet:           opts.Quiet,
+		reporter:        opts.StatusReporter,
+		downloadID:      opts.DownloadID,
+		ctx:             opts.Context,
 	}
 }
 
 func (d *TorrentDownloader) Download() error {
+	if d.ctx == nil {
+		d.ctx = context.Background()
+	}
+
 	cfg := torrent.NewDefaultClientConfig()
 	if info, err := os.Stat(d.outPath); err == nil && info.IsDir() {
 		cfg.DataDir = d.outPath
@@ -52,13 +99,46 @@ func (d *TorrentDownloader) Download() error {
 		cfg.DataDir = filepath.Dir(d.outPath)
 	}
 
-	if d.uploadLimit > 0 {
+	storageImpl, pieceCompletion, err := newStorage(d.storageKind, cfg.DataDir)
+	if err != nil {
+		return err
+	}
+	cfg.DefaultStorage = storageImpl
+
+	if d.uploadLimiter != nil {
+		cfg.UploadRateLimiter = d.uploadLimiter
+	} else if d.uploadLimit > 0 {
 		cfg.UploadRateLimiter = rate.NewLimiter(rate.Limit(d.uploadLimit), int(d.uploadLimit))
 	}
-	if d.downloadLimit > 0 {
+	if d.downloadLimiter != nil {
+		cfg.DownloadRateLimiter = d.downloadLimiter
+	} else if d.downloadLimit > 0 {
 		cfg.DownloadRateLimiter = rate.NewLimiter(rate.Limit(d.downloadLimit), int(d.downloadLimit))
 	}
 
+	cfg.DisableUTP = d.disableUTP
+	cfg.DisableTCP = d.disableTCP
+	cfg.DisableIPv6 = d.disableIPv6
+
+	if d.blocklistSrc != "" {
+		ranger, err := loadIPBlocklistSource(d.blocklistSrc)
+		if err != nil {
+			// A bad/unreachable blocklist shouldn't block the download outright -
+			// report it and continue without filtering, same as resolveRedirects
+			// tolerating a failed HEAD probe.
+			if d.reporter != nil {
+				d.reporter.Report(map[string]interface{}{
+					"type":    "torrent",
+					"status":  "warning",
+					"message": fmt.Sprintf("failed to load IP blocklist: %v", err),
+				})
+			}
+		} else {
+			d.blocklist = newIPBlocklist(ranger)
+			cfg.IPBlocklist = d.blocklist
+		}
+	}
+
 	client, err := torrent.NewClient(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create torrent client: %w", err)
@@ -101,18 +181,29 @@ func (d *TorrentDownloader) Download() error {
 		}
 	}
 
-	t.DownloadAll()
-	if d.sequential {
-		for _, f := range t.Files() {
-			f.SetPriority(torrent.PiecePriorityNow)
+	if len(d.trackers) > 0 {
+		t.AddTrackers([][]string{d.trackers})
+	}
+
+	if !d.stream {
+		t.DownloadAll()
+		if d.sequential {
+			for _, f := range t.Files() {
+				f.SetPriority(torrent.PiecePriorityNow)
+			}
 		}
 	}
 
 	if d.reporter != nil {
+		storageBackend := d.storageKind
+		if storageBackend == "" {
+			storageBackend = "file"
+		}
 		d.reporter.Report(map[string]interface{}{
-			"type":     "torrent",
-			"status":   "getting_metadata",
-			"progress": 0.0,
+			"type":            "torrent",
+			"status":          "getting_metadata",
+			"progress":        0.0,
+			"storage_backend": storageBackend,
 		})
 	}
 
@@ -123,12 +214,49 @@ func (d *TorrentDownloader) Download() error {
 		return fmt.Errorf("failed to get torrent info")
 	}
 
+	if pieceCompletion != nil {
+		if err := d.seedPieceCompletionFromSidecar(pieceCompletion, t.InfoHash(), info.NumPieces(), cfg.DataDir); err != nil && d.reporter != nil {
+			d.reporter.Report(map[string]interface{}{
+				"type":    "torrent",
+				"status":  "warning",
+				"message": fmt.Sprintf("failed to seed piece completion from imported resume state: %v", err),
+			})
+		}
+	}
+
+	var selected []bool
+	if d.fileSelect != nil {
+		files := t.Files()
+		selected = resolveFileSelection(files, d.fileSelect)
+		if d.reporter != nil {
+			d.reporter.Report(map[string]interface{}{
+				"type":           "torrent",
+				"status":         "file_selection",
+				"selected_files": selectedFilePaths(files, selected),
+			})
+		}
+	}
+
+	if len(d.piecePriority) > 0 {
+		d.applyPiecePriority(t, info.NumPieces())
+	}
+
+	// Merge user-supplied webseeds with any url-list advertised in the torrent's own metainfo
+	d.mergeWebSeeds(t.Metainfo().UrlList)
+	if len(d.webSeeds) > 0 {
+		go d.runWebSeedFallback(t, info, cfg.DataDir)
+	}
+
+	if d.blocklist != nil && d.blocklistTTL > 0 {
+		go d.runBlocklistRefresh(t)
+	}
+
 	// Check existing files and verify pieces before starting
 	if d.reporter != nil {
 		d.reporter.Report(map[string]interface{}{
-			"type":     "torrent",
-			"status":   "verifying",
-			"progress": 0.0,
+			"type":          "torrent",
+			"status":        "verifying",
+			"progress":      0.0,
 			"verify_status": "checking_existing_files",
 		})
 	}
@@ -171,23 +299,29 @@ func (d *TorrentDownloader) Download() error {
 				"verify_status": "verifying_pieces",
 			})
 		}
-		
+
 		// Wait a bit for initial verification to complete
 		time.Sleep(500 * time.Millisecond)
 	}
 
+	if d.stream {
+		return d.runStream(t, info)
+	}
+
 	// For multi-file torrents, enable parallel downloads by not using sequential mode
 	// unless explicitly requested
-	if !d.sequential {
-		// Set all files to normal priority to allow parallel downloads
-		for _, f := range t.Files() {
-			f.SetPriority(torrent.PiecePriorityNormal)
-		}
-	} else {
+	wantedPriority := torrent.PiecePriorityNormal
+	if d.sequential {
 		// Sequential mode - download files one by one
-		for _, f := range t.Files() {
-			f.SetPriority(torrent.PiecePriorityNow)
+		wantedPriority = torrent.PiecePriorityNow
+	}
+	for i, f := range t.Files() {
+		if d.fileSelect != nil && !selected[i] {
+			// Excluded by BTFileSelect - never touch disk for this file.
+			f.SetPriority(torrent.PiecePriorityNone)
+			continue
 		}
+		f.SetPriority(wantedPriority)
 	}
 
 	lastPieceStateUpdate := time.Now()
@@ -195,13 +329,16 @@ func (d *TorrentDownloader) Download() error {
 	lastBytesRead := int64(0)
 	lastBytesWritten := int64(0)
 	speedHistory := make([]int64, 0, 10) // Keep last 10 speed samples for smoothing
-	lastValidSpeed := int64(0) // Keep last valid speed to persist when connection drops
+	lastValidSpeed := int64(0)           // Keep last valid speed to persist when connection drops
 	lastValidUploadSpeed := int64(0)
 	ticker := time.NewTicker(200 * time.Millisecond) // More frequent updates for torrents
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-d.ctx.Done():
+			t.Drop()
+			return d.ctx.Err()
 		case <-t.Closed():
 			return nil
 		case <-ticker.C:
@@ -219,27 +356,34 @@ func (d *TorrentDownloader) Download() error {
 				}
 			}
 
+			metricsBytesRead.Set(stats.BytesReadUsefulData.Int64())
+			metricsBytesWritten.Set(stats.BytesWrittenData.Int64())
+			setActivePeers(int64(stats.ActivePeers))
+			setConnectedSeeders(int64(stats.ConnectedSeeders))
+			setActiveConns(int64(stats.ActivePeers)) // one peer connection each, for the torrent path
+			setDownloadProgress(d.downloadID, progress)
+
 			// Always report for torrents to show activity
 			if d.reporter != nil {
 				now := time.Now()
 				currentBytesRead := stats.BytesReadUsefulData.Int64()
 				currentBytesWritten := stats.BytesWrittenData.Int64()
-				
+
 				// Calculate download rate from cumulative bytes read
 				// BytesReadUsefulData is cumulative, so we need to track the difference
 				var downloadRate int64 = 0
 				var uploadRate int64 = 0
-				
+
 				elapsed := now.Sub(lastStatsTime).Seconds()
 				if elapsed > 0 && lastStatsTime.After(time.Time{}) {
 					// Calculate rate from cumulative counter difference
 					bytesReadDelta := currentBytesRead - lastBytesRead
 					bytesWrittenDelta := currentBytesWritten - lastBytesWritten
-					
+
 					// Only calculate if delta is non-negative (counters should only increase)
 					if bytesReadDelta >= 0 {
 						instantRate := int64(float64(bytesReadDelta) / elapsed)
-						
+
 						// Only add non-zero rates to history (connection is active)
 						if instantRate > 0 {
 							// Add to speed history for smoothing (keep last 10 samples, ~2 seconds of data)
@@ -247,7 +391,7 @@ func (d *TorrentDownloader) Download() error {
 							if len(speedHistory) > 10 {
 								speedHistory = speedHistory[1:]
 							}
-							
+
 							// Calculate smoothed average speed from history
 							if len(speedHistory) > 0 {
 								var sum int64 = 0
@@ -265,7 +409,7 @@ func (d *TorrentDownloader) Download() error {
 						// Counter went backwards (shouldn't happen) - use last valid speed
 						downloadRate = lastValidSpeed
 					}
-					
+
 					if bytesWrittenDelta >= 0 {
 						calculatedUploadRate := int64(float64(bytesWrittenDelta) / elapsed)
 						if calculatedUploadRate > 0 {
@@ -282,32 +426,25 @@ func (d *TorrentDownloader) Download() error {
 					downloadRate = 0
 					uploadRate = 0
 				}
-				
+
 				// Update tracking variables
 				lastBytesRead = currentBytesRead
 				lastBytesWritten = currentBytesWritten
 				lastStatsTime = now
-				
+
 				// Calculate ETA based on current download rate
 				var eta float64 = 0
 				if downloadRate > 0 && totalBytes > 0 && completedBytes < totalBytes {
 					remaining := totalBytes - completedBytes
 					eta = float64(remaining) / float64(downloadRate)
 				}
-				
-				// Get piece completion state for integrity tracking
+
+				// Get piece completion state for integrity tracking, run-length
+				// encoded so a 50k-piece torrent doesn't emit a 50KB bool array
+				// every 200ms.
 				pieceCount := t.NumPieces()
-				completedPieces := 0
-				pieceStates := make([]bool, pieceCount)
-				for i := 0; i < pieceCount; i++ {
-					pieceState := t.PieceState(i)
-					isComplete := pieceState.Complete
-					pieceStates[i] = isComplete
-					if isComplete {
-						completedPieces++
-					}
-				}
-				
+				pieceRuns, completedPieces, partialPieces, checkingPieces := summarizePieceStates(t)
+
 				// Get file-level progress for multi-file torrents
 				var fileProgress []map[string]interface{}
 				if info != nil && len(info.Files) > 1 {
@@ -320,7 +457,7 @@ func (d *TorrentDownloader) Download() error {
 						if fileTotal > 0 {
 							fileProgressValue = float64(fileCompleted) / float64(fileTotal)
 						}
-						
+
 						// Build file path
 						filePath := ""
 						if len(fileInfo.Path) > 0 {
@@ -328,54 +465,62 @@ func (d *TorrentDownloader) Download() error {
 						} else {
 							filePath = info.Name
 						}
-						
+
 						fileProgress = append(fileProgress, map[string]interface{}{
-							"index":     i,
-							"path":      filePath,
-							"name":      filepath.Base(filePath),
-							"progress":  fileProgressValue,
+							"index":      i,
+							"path":       filePath,
+							"name":       filepath.Base(filePath),
+							"progress":   fileProgressValue,
 							"downloaded": fileCompleted,
-							"total":     fileTotal,
+							"total":      fileTotal,
 						})
 					}
 				}
-				
+
 				reportData := map[string]interface{}{
-					"type":          "torrent",
-					"status":        "downloading",
-					"progress":      progress,
-					"downloaded":    completedBytes,
-					"total":         totalBytes,
-					"download_rate": downloadRate,
-					"speed":         downloadRate,
-					"upload_rate":   uploadRate,
-					"peers":         stats.ActivePeers,
-					"seeds":         stats.ConnectedSeeders,
-					"eta":           eta,
-					"piece_count":   pieceCount,
+					"type":             "torrent",
+					"status":           "downloading",
+					"progress":         progress,
+					"downloaded":       completedBytes,
+					"total":            totalBytes,
+					"download_rate":    downloadRate,
+					"speed":            downloadRate,
+					"upload_rate":      uploadRate,
+					"peers":            stats.ActivePeers,
+					"seeds":            stats.ConnectedSeeders,
+					"eta":              eta,
+					"piece_count":      pieceCount,
 					"completed_pieces": completedPieces,
-					"piece_states":  pieceStates, // For integrity verification
+					"partial_pieces":   partialPieces,
+					"checking_pieces":  checkingPieces,
+					"piece_states":     pieceRuns, // run-length encoded; see summarizePieceStates
+				}
+				if d.emitPieceBitmap {
+					reportData["piece_bitmap"] = pieceBitmap(t)
+				}
+				if d.blocklist != nil {
+					reportData["blocked_peers"] = d.blocklist.BlockedCount()
 				}
-				
+
 				// Add file progress if available
 				if len(fileProgress) > 0 {
 					reportData["file_progress"] = fileProgress
 				}
-				
+
 				// Add torrent name (file/folder name) if available
 				if info != nil && info.Name != "" {
 					reportData["torrent_name"] = info.Name
 				}
-				
+
 				// Add info hash for state persistence
 				infoHash := t.InfoHash()
 				infoHashStr := infoHash.HexString()
 				if infoHashStr != "" {
 					reportData["info_hash"] = infoHashStr
 				}
-				
+
 				d.reporter.Report(reportData)
-				
+
 				// Update piece state in database periodically (every 5 seconds)
 				if time.Since(lastPieceStateUpdate) > 5*time.Second && d.downloadID != "" {
 					// Piece states are included in the report, which will be stored in metadata
@@ -386,32 +531,28 @@ func (d *TorrentDownloader) Download() error {
 			if totalBytes > 0 && completedBytes >= totalBytes {
 				// Get final piece state for integrity verification
 				pieceCount := t.NumPieces()
-				completedPieces := 0
-				pieceStates := make([]bool, pieceCount)
-				for i := 0; i < pieceCount; i++ {
-					pieceState := t.PieceState(i)
-					isComplete := pieceState.Complete
-					pieceStates[i] = isComplete
-					if isComplete {
-						completedPieces++
-					}
-				}
-				
+				pieceRuns, completedPieces, partialPieces, checkingPieces := summarizePieceStates(t)
+
 				if d.reporter != nil {
 					reportData := map[string]interface{}{
-						"type":            "torrent",
-						"status":          "seeding",
-						"progress":        1.0,
-						"downloaded":      completedBytes,
-						"total":           totalBytes,
-						"download_rate":   int64(stats.BytesReadUsefulData.Int64()),
-						"upload_rate":     int64(stats.BytesWrittenData.Int64()),
-						"peers":           stats.ActivePeers,
-						"seeds":           stats.ConnectedSeeders,
-						"piece_count":     pieceCount,
+						"type":             "torrent",
+						"status":           "seeding",
+						"progress":         1.0,
+						"downloaded":       completedBytes,
+						"total":            totalBytes,
+						"download_rate":    int64(stats.BytesReadUsefulData.Int64()),
+						"upload_rate":      int64(stats.BytesWrittenData.Int64()),
+						"peers":            stats.ActivePeers,
+						"seeds":            stats.ConnectedSeeders,
+						"piece_count":      pieceCount,
 						"completed_pieces": completedPieces,
-						"piece_states":    pieceStates,
-						"verify_status":   "verified",
+						"partial_pieces":   partialPieces,
+						"checking_pieces":  checkingPieces,
+						"piece_states":     pieceRuns,
+						"verify_status":    "verified",
+					}
+					if d.emitPieceBitmap {
+						reportData["piece_bitmap"] = pieceBitmap(t)
 					}
 					if info != nil && info.Name != "" {
 						reportData["torrent_name"] = info.Name
@@ -423,30 +564,35 @@ func (d *TorrentDownloader) Download() error {
 					}
 					d.reporter.Report(reportData)
 				}
-				
+
 				// If keepSeeding is false, exit after completion
 				if !d.keepSeeding {
 					return nil
 				}
-				
+
 				// Continue seeding and reporting stats
 				if d.reporter != nil {
 					reportData := map[string]interface{}{
-						"type":            "torrent",
-						"status":          "seeding",
-						"progress":        1.0,
-						"downloaded":      completedBytes,
-						"total":           totalBytes,
-						"download_rate":   int64(stats.BytesReadUsefulData.Int64()),
-						"speed":           int64(stats.BytesReadUsefulData.Int64()),
-						"upload_rate":     int64(stats.BytesWrittenData.Int64()),
-						"peers":           stats.ActivePeers,
-						"seeds":           stats.ConnectedSeeders,
-						"eta":             0,
-						"piece_count":     pieceCount,
+						"type":             "torrent",
+						"status":           "seeding",
+						"progress":         1.0,
+						"downloaded":       completedBytes,
+						"total":            totalBytes,
+						"download_rate":    int64(stats.BytesReadUsefulData.Int64()),
+						"speed":            int64(stats.BytesReadUsefulData.Int64()),
+						"upload_rate":      int64(stats.BytesWrittenData.Int64()),
+						"peers":            stats.ActivePeers,
+						"seeds":            stats.ConnectedSeeders,
+						"eta":              0,
+						"piece_count":      pieceCount,
 						"completed_pieces": completedPieces,
-						"piece_states":    pieceStates,
-						"verify_status":   "verified",
+						"partial_pieces":   partialPieces,
+						"checking_pieces":  checkingPieces,
+						"piece_states":     pieceRuns,
+						"verify_status":    "verified",
+					}
+					if d.emitPieceBitmap {
+						reportData["piece_bitmap"] = pieceBitmap(t)
 					}
 					if info != nil && info.Name != "" {
 						reportData["torrent_name"] = info.Name
@@ -462,3 +608,270 @@ func (d *TorrentDownloader) Download() error {
 		}
 	}
 }
+
+// seedPieceCompletionFromSidecar looks for a "<info-hash>.piece-bitmap"
+// sidecar (as written by Import) in dataDir and, if found, marks every piece
+// it covers complete in completion, so a torrent resumed from an imported
+// qBittorrent/Transmission/libtorrent resume file doesn't re-hash data the
+// original client had already verified. A missing sidecar is not an error -
+// most downloads were never imported.
+func (d *TorrentDownloader) seedPieceCompletionFromSidecar(completion storage.PieceCompletion, infoHash metainfo.Hash, numPieces int, dataDir string) error {
+	sidecarPath := filepath.Join(dataDir, infoHash.HexString()+".piece-bitmap")
+	bitmap, err := os.ReadFile(sidecarPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < numPieces; i++ {
+		byteIdx := i / 8
+		if byteIdx >= len(bitmap) {
+			break
+		}
+		if bitmap[byteIdx]&(1<<uint(7-i%8)) == 0 {
+			continue
+		}
+		if err := completion.Set(metainfo.PieceKey{InfoHash: infoHash, Index: i}, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPiecePriority sets each piece in [0, numPieces) to PiecePriorityNone
+// where d.piecePriority marks it skipped (priority byte 0) and leaves every
+// other piece at its default priority; it does not attempt to reproduce
+// libtorrent's finer-grained priority levels.
+func (d *TorrentDownloader) applyPiecePriority(t *torrent.Torrent, numPieces int) {
+	for i := 0; i < numPieces && i < len(d.piecePriority); i++ {
+		if d.piecePriority[i] == 0 {
+			t.Piece(i).SetPriority(torrent.PiecePriorityNone)
+		}
+	}
+}
+
+// mergeWebSeeds adds any not-already-known webseed URLs (e.g. from the
+// torrent's own url-list) to d.webSeeds.
+func (d *TorrentDownloader) mergeWebSeeds(urls []string) {
+	seen := make(map[string]bool, len(d.webSeeds))
+	for _, u := range d.webSeeds {
+		seen[u] = true
+	}
+	for _, u := range urls {
+		if u != "" && !seen[u] {
+			d.webSeeds = append(d.webSeeds, u)
+			seen[u] = true
+		}
+	}
+}
+
+// webSeedFallbackConcurrency bounds how many pieces runWebSeedFallback fetches
+// at once, so a starved torrent with many webseeds doesn't open an unbounded
+// number of ranged GETs against the same mirror.
+const webSeedFallbackConcurrency = 4
+
+// runWebSeedFallback races HTTP GETs against the swarm for pieces that are
+// still missing once the swarm looks starved (no peers connected), fetching
+// them directly from the advertised webseeds - in parallel, up to
+// webSeedFallbackConcurrency at a time - and verifying each against the
+// torrent's own piece hash before it's trusted.
+func (d *TorrentDownloader) runWebSeedFallback(t *torrent.Torrent, info *metainfo.Info, dataDir string) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.Closed():
+			return
+		case <-ticker.C:
+			if t.BytesMissing() == 0 {
+				return
+			}
+			if t.Stats().ActivePeers > 0 {
+				continue
+			}
+			d.fetchMissingPiecesFromWebSeeds(t, info, dataDir)
+		}
+	}
+}
+
+// fetchMissingPiecesFromWebSeeds fetches every piece the swarm hasn't
+// completed from d.webSeeds, up to webSeedFallbackConcurrency at once.
+func (d *TorrentDownloader) fetchMissingPiecesFromWebSeeds(t *torrent.Torrent, info *metainfo.Info, dataDir string) {
+	sem := make(chan struct{}, webSeedFallbackConcurrency)
+	var wg sync.WaitGroup
+
+	for i := 0; i < t.NumPieces(); i++ {
+		if t.PieceState(i).Complete {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := d.fetchPieceFromWebSeeds(t, info, dataDir, idx); err != nil && d.reporter != nil {
+				d.reporter.Report(map[string]interface{}{
+					"type":    "torrent",
+					"status":  "warning",
+					"message": fmt.Sprintf("webseed fallback failed for piece %d: %v", idx, err),
+				})
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// runBlocklistRefresh re-fetches a URL-sourced IP blocklist on an interval
+// and swaps it into d.blocklist, so a long-running download picks up list
+// updates without restarting the client.
+func (d *TorrentDownloader) runBlocklistRefresh(t *torrent.Torrent) {
+	ticker := time.NewTicker(d.blocklistTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.Closed():
+			return
+		case <-ticker.C:
+			ranger, err := loadIPBlocklistSource(d.blocklistSrc)
+			if err != nil {
+				continue
+			}
+			d.blocklist.set(ranger)
+		}
+	}
+}
+
+// fetchPieceFromWebSeeds downloads a single piece from the first webseed that
+// answers, writes it through the torrent's own selected storage backend (so
+// it's subject to whatever -bt-storage mode is in effect, same as a
+// swarm-fetched piece), and has the torrent client verify and mark it
+// complete so runWebSeedFallback stops re-fetching it.
+func (d *TorrentDownloader) fetchPieceFromWebSeeds(t *torrent.Torrent, info *metainfo.Info, dataDir string, pieceIndex int) error {
+	piece := t.Piece(pieceIndex)
+	pieceLen := int64(piece.Info().Length())
+	pieceOffset := int64(pieceIndex) * info.PieceLength
+
+	buf := make([]byte, pieceLen)
+	var lastErr error
+	for _, webSeed := range d.webSeeds {
+		n, err := d.readRangeFromWebSeed(webSeed, info, pieceOffset, pieceLen, buf)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		expected := info.Pieces[pieceIndex*sha1.Size : (pieceIndex+1)*sha1.Size]
+		if got := sha1.Sum(buf[:n]); string(got[:]) != string(expected) {
+			recordPieceHashFailure()
+			lastErr = fmt.Errorf("webseed piece %d: hash mismatch", pieceIndex)
+			continue
+		}
+		if _, err := piece.Storage().WriteAt(buf[:n], 0); err != nil {
+			lastErr = fmt.Errorf("webseed piece %d: %w", pieceIndex, err)
+			continue
+		}
+		piece.VerifyData()
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("webseed piece %d: no webseeds available", pieceIndex)
+	}
+	return lastErr
+}
+
+// readRangeFromWebSeed fetches [offset, offset+length) from a BEP 19 webseed,
+// issuing one ranged GET per file the range spans and writing each file's
+// bytes at its corresponding position in buf. In a multi-file torrent a piece
+// routinely straddles a file boundary (file lengths aren't piece-aligned), so
+// this can't assume the whole range lives in a single file.
+func (d *TorrentDownloader) readRangeFromWebSeed(webSeed string, info *metainfo.Info, offset, length int64, buf []byte) (int, error) {
+	segments, err := webSeedSegments(webSeed, info, offset, length)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, seg := range segments {
+		req, err := http.NewRequest("GET", seg.url, nil)
+		if err != nil {
+			return total, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", seg.fileOffset, seg.fileOffset+seg.length-1))
+
+		n, err := func() (int, error) {
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return 0, err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+				return 0, fmt.Errorf("webseed %s: unexpected status %d", webSeed, resp.StatusCode)
+			}
+			return io.ReadFull(resp.Body, buf[seg.bufOffset:seg.bufOffset+seg.length])
+		}()
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// webSeedSegment is one ranged GET needed to fill part of a requested range
+// from a single file within a webseed, and where that file's bytes land in
+// the caller's destination buffer.
+type webSeedSegment struct {
+	url        string
+	fileOffset int64
+	length     int64
+	bufOffset  int64
+}
+
+// webSeedSegments maps a torrent-wide byte range to the webseed URL(s) and
+// per-file sub-ranges needed to cover it, walking info.Files the same way the
+// rest of the downloader addresses multi-file layouts. A single-file torrent
+// always yields one segment; a multi-file torrent yields one segment per file
+// the range overlaps, in order.
+func webSeedSegments(webSeed string, info *metainfo.Info, offset, length int64) ([]webSeedSegment, error) {
+	base := strings.TrimRight(webSeed, "/")
+	if !info.IsDir() {
+		return []webSeedSegment{{url: base, fileOffset: offset, length: length, bufOffset: 0}}, nil
+	}
+
+	end := offset + length
+	var segments []webSeedSegment
+	fileStart := int64(0)
+	for _, file := range info.Files {
+		fileEnd := fileStart + file.Length
+		if end <= fileStart {
+			break
+		}
+		if offset < fileEnd {
+			segStart := offset
+			if segStart < fileStart {
+				segStart = fileStart
+			}
+			segEnd := end
+			if segEnd > fileEnd {
+				segEnd = fileEnd
+			}
+			parts := append([]string{base, info.Name}, file.Path...)
+			segments = append(segments, webSeedSegment{
+				url:        strings.Join(parts, "/"),
+				fileOffset: segStart - fileStart,
+				length:     segEnd - segStart,
+				bufOffset:  segStart - offset,
+			})
+		}
+		fileStart = fileEnd
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("offset %d out of range for torrent %q", offset, info.Name)
+	}
+	return segments, nil
+}
@@ -0,0 +1,119 @@
+package downloader
+
+import (
+	"path"
+	"strings"
+
+	"github.com/anacrolix/torrent"
+)
+
+// FileSelector narrows which files of a multi-file torrent actually get
+// downloaded. A nil selector (the zero value for Options.BTFileSelect)
+// selects every file, matching the previous all-or-nothing behavior.
+type FileSelector struct {
+	Indices    []int    // explicit 0-based file indices to include
+	Globs      []string // glob patterns matched against each file's slash-joined path; a "!"-prefixed pattern excludes instead of includes
+	MinSize    int64    // files smaller than this are excluded
+	MaxSize    int64    // files larger than this are excluded; 0 means no limit
+	Extensions []string // e.g. []string{".mkv", ".mp4"}; files without one of these extensions are excluded
+}
+
+// resolveFileSelection returns, for each file in files (in the same order),
+// whether sel selects it. Indices and Globs are alternative ways to name the
+// base set of wanted files; MinSize/MaxSize/Extensions then refine whichever
+// base set Indices or Globs produced. A nil sel selects every file.
+func resolveFileSelection(files []*torrent.File, sel *FileSelector) []bool {
+	selected := make([]bool, len(files))
+	if sel == nil {
+		for i := range selected {
+			selected[i] = true
+		}
+		return selected
+	}
+
+	switch {
+	case len(sel.Indices) > 0:
+		want := make(map[int]bool, len(sel.Indices))
+		for _, idx := range sel.Indices {
+			want[idx] = true
+		}
+		for i := range selected {
+			selected[i] = want[i]
+		}
+	case len(sel.Globs) > 0:
+		havePositive := false
+		for _, g := range sel.Globs {
+			if !strings.HasPrefix(g, "!") {
+				havePositive = true
+				break
+			}
+		}
+		for i, f := range files {
+			filePath := path.Join(f.FileInfo().Path...)
+			include := !havePositive
+			for _, g := range sel.Globs {
+				negate := strings.HasPrefix(g, "!")
+				pattern := strings.TrimPrefix(g, "!")
+				// path.Match's "*" never crosses a "/", so an extension-only
+				// pattern like "*.mkv" would only ever match root-level
+				// files. Match those against the file's base name instead;
+				// a pattern that names a directory (has its own "/", e.g.
+				// "sample/*") still matches against the full path.
+				target := filePath
+				if !strings.Contains(pattern, "/") {
+					target = path.Base(filePath)
+				}
+				if ok, _ := path.Match(pattern, target); ok {
+					include = !negate
+				}
+			}
+			selected[i] = include
+		}
+	default:
+		for i := range selected {
+			selected[i] = true
+		}
+	}
+
+	for i, f := range files {
+		if !selected[i] {
+			continue
+		}
+		size := f.FileInfo().Length
+		if sel.MinSize > 0 && size < sel.MinSize {
+			selected[i] = false
+			continue
+		}
+		if sel.MaxSize > 0 && size > sel.MaxSize {
+			selected[i] = false
+			continue
+		}
+		if len(sel.Extensions) > 0 {
+			filePath := path.Join(f.FileInfo().Path...)
+			matched := false
+			for _, ext := range sel.Extensions {
+				if strings.HasSuffix(filePath, ext) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				selected[i] = false
+			}
+		}
+	}
+
+	return selected
+}
+
+// selectedFilePaths returns the slash-joined paths of the files selected is
+// true for, in file order, for inclusion in a status report.
+func selectedFilePaths(files []*torrent.File, selected []bool) []string {
+	var names []string
+	for i, f := range files {
+		if selected[i] {
+			names = append(names, path.Join(f.FileInfo().Path...))
+		}
+	}
+	return names
+}
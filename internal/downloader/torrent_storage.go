@@ -0,0 +1,307 @@
+package downloader
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+)
+
+// newStorage builds the storage.ClientImpl selected by Options.BTStorage.
+// "file" (the default) is anacrolix/torrent's normal on-disk layout, backed
+// by a bbolt piece-completion database in dataDir so resume across process
+// restarts reads real completion state instead of re-deriving it from file
+// lengths. "mmap" memory-maps piece data to avoid read/write syscalls on
+// large sequential access. "piece-file" keeps incomplete pieces in a
+// separate per-infohash directory from finished file data, which keeps a
+// half-downloaded file from ever containing garbage past what's actually
+// been verified. "piece" goes further and keys each piece's file by its
+// content hash instead of its torrent/index, under a shared dataDir/.pieces
+// directory, so two torrents that happen to share a piece (e.g. different
+// releases of the same source) store and verify it once; see
+// newPieceFileStorage. "memory" keeps every piece in RAM, for --inspect/
+// verification runs that shouldn't touch disk at all.
+// newStorage also returns the backend's storage.PieceCompletion, when it has
+// one, so callers can seed it from an imported resume file's piece-bitmap
+// sidecar before the torrent client starts verifying pieces. Only "file" and
+// "piece" have one; the others track completion their own way and return
+// nil.
+func newStorage(kind, dataDir string) (storage.ClientImpl, storage.PieceCompletion, error) {
+	switch kind {
+	case "", "file":
+		completion, err := storage.NewBoltPieceCompletion(dataDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open piece-completion database: %w", err)
+		}
+		return storage.NewFileWithCompletion(dataDir, completion), completion, nil
+	case "mmap":
+		return storage.NewMMap(dataDir), nil, nil
+	case "piece-file":
+		return storage.NewFileByInfoHash(dataDir), nil, nil
+	case "piece":
+		return newPieceFileStorage(dataDir)
+	case "memory":
+		return newMemoryStorage(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown bt-storage backend %q", kind)
+	}
+}
+
+// pieceFileStorage implements storage.ClientImpl for the "piece" BTStorage
+// kind. Each piece is kept in its own file under dataDir/.pieces, named by
+// the piece's SHA1 hash rather than its torrent/index, so the same piece
+// downloaded by two different torrents (or re-downloaded after dataDir is
+// reused) is only ever fetched and stored once. A piece is written to a
+// per-torrent scratch file while incomplete, then renamed into its final
+// content-addressed name once MarkComplete confirms the hash checked out -
+// at which point it's also copied into every destination file byte range it
+// overlaps, so the torrent's normal named output files exist incrementally
+// as pieces finish, the same way "piece-file" keeps incomplete data
+// separate from finished file data. Completion is tracked the same way
+// "file" does, via a bbolt database in dataDir.
+type pieceFileStorage struct {
+	dataDir    string
+	piecesDir  string
+	completion storage.PieceCompletion
+}
+
+// newPieceFileStorage builds the "piece" storage.ClientImpl described above,
+// along with its bbolt-backed storage.PieceCompletion.
+func newPieceFileStorage(dataDir string) (storage.ClientImpl, storage.PieceCompletion, error) {
+	piecesDir := filepath.Join(dataDir, ".pieces")
+	if err := os.MkdirAll(piecesDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create piece store directory: %w", err)
+	}
+	completion, err := storage.NewBoltPieceCompletion(dataDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open piece-completion database: %w", err)
+	}
+	return &pieceFileStorage{dataDir: dataDir, piecesDir: piecesDir, completion: completion}, completion, nil
+}
+
+func (s *pieceFileStorage) OpenTorrent(info *metainfo.Info, infoHash metainfo.Hash) (storage.TorrentImpl, error) {
+	t := &pieceFileTorrent{storage: s, info: info, infoHash: infoHash}
+	return storage.TorrentImpl{Piece: t.Piece, Close: func() error { return nil }}, nil
+}
+
+func (s *pieceFileStorage) Close() error { return s.completion.Close() }
+
+type pieceFileTorrent struct {
+	storage  *pieceFileStorage
+	info     *metainfo.Info
+	infoHash metainfo.Hash
+}
+
+func (t *pieceFileTorrent) Piece(p metainfo.Piece) storage.PieceImpl {
+	hash := p.Hash()
+	return &pieceFilePiece{
+		storage:   t.storage,
+		info:      t.info,
+		finalPath: filepath.Join(t.storage.piecesDir, hex.EncodeToString(hash[:])),
+		tempPath:  filepath.Join(t.storage.piecesDir, fmt.Sprintf(".tmp-%s-%d", hex.EncodeToString(t.infoHash[:]), p.Index())),
+		offset:    p.Offset(),
+		length:    p.Length(),
+		key:       metainfo.PieceKey{InfoHash: t.infoHash, Index: p.Index()},
+	}
+}
+
+// pieceFilePiece implements storage.PieceImpl over a single content-
+// addressed piece file, falling back to a per-torrent scratch file while
+// the piece is still being written.
+type pieceFilePiece struct {
+	storage   *pieceFileStorage
+	info      *metainfo.Info
+	finalPath string
+	tempPath  string
+	offset    int64 // this piece's byte offset in the torrent's concatenated file stream
+	length    int64
+	key       metainfo.PieceKey
+}
+
+// ReadAt tries finalPath first and only falls back to tempPath on a missing
+// file, rather than stat-ing first and opening second, so a MarkComplete
+// rename landing between those two steps can't make a freshly-completed
+// piece look like it doesn't exist.
+func (p *pieceFilePiece) ReadAt(b []byte, off int64) (int, error) {
+	f, err := os.Open(p.finalPath)
+	if os.IsNotExist(err) {
+		f, err = os.Open(p.tempPath)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	defer f.Close()
+	return f.ReadAt(b, off)
+}
+
+func (p *pieceFilePiece) WriteAt(b []byte, off int64) (int, error) {
+	if _, err := os.Stat(p.finalPath); err == nil {
+		// Some other torrent (or an earlier run) already has this exact
+		// piece; writing our own copy would only duplicate bytes on disk.
+		return len(b), nil
+	}
+	f, err := os.OpenFile(p.tempPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.WriteAt(b, off)
+}
+
+func (p *pieceFilePiece) MarkComplete() error {
+	if _, err := os.Stat(p.finalPath); err != nil {
+		if err := os.Rename(p.tempPath, p.finalPath); err != nil {
+			return fmt.Errorf("failed to finalize piece %s: %w", hex.EncodeToString(p.key.InfoHash[:]), err)
+		}
+	} else {
+		os.Remove(p.tempPath)
+	}
+	if err := p.assembleIntoFiles(); err != nil {
+		return err
+	}
+	return p.storage.completion.Set(p.key, true)
+}
+
+func (p *pieceFilePiece) MarkNotComplete() error {
+	return p.storage.completion.Set(p.key, false)
+}
+
+func (p *pieceFilePiece) Completion() storage.Completion {
+	c, err := p.storage.completion.Get(p.key)
+	if err != nil {
+		return storage.Completion{Complete: false, Ok: false}
+	}
+	return storage.Completion{Complete: c.Complete, Ok: true}
+}
+
+// assembleIntoFiles copies this now-verified piece's bytes into every
+// destination file byte range it overlaps, creating the files (and their
+// parent directories) under dataDir/info.Name as needed - the same layout
+// TorrentDownloader.Download already expects when checking existing file
+// sizes.
+func (p *pieceFilePiece) assembleIntoFiles() error {
+	src, err := os.Open(p.finalPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	pieceStart, pieceEnd := p.offset, p.offset+p.length
+	fileStart := int64(0)
+	for _, f := range p.info.UpvertedFiles() {
+		fileEnd := fileStart + f.Length
+		overlapStart, overlapEnd := max(pieceStart, fileStart), min(pieceEnd, fileEnd)
+		if overlapStart < overlapEnd {
+			buf := make([]byte, overlapEnd-overlapStart)
+			if _, err := src.ReadAt(buf, overlapStart-pieceStart); err != nil {
+				return fmt.Errorf("failed to read assembled piece data: %w", err)
+			}
+			if err := p.writeFileRange(f, overlapStart-fileStart, buf); err != nil {
+				return err
+			}
+		}
+		fileStart = fileEnd
+	}
+	return nil
+}
+
+func (p *pieceFilePiece) writeFileRange(f metainfo.FileInfo, offset int64, data []byte) error {
+	parts := append([]string{p.info.Name}, f.Path...)
+	path := filepath.Join(append([]string{p.storage.dataDir}, parts...)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory for %s: %w", path, err)
+	}
+	dst, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open destination file %s: %w", path, err)
+	}
+	defer dst.Close()
+	_, err = dst.WriteAt(data, offset)
+	return err
+}
+
+// memoryStorage is a storage.ClientImpl that keeps all piece data in RAM
+// instead of writing it to dataDir.
+type memoryStorage struct{}
+
+func newMemoryStorage() *memoryStorage { return &memoryStorage{} }
+
+func (m *memoryStorage) OpenTorrent(info *metainfo.Info, infoHash metainfo.Hash) (storage.TorrentImpl, error) {
+	t := &memoryTorrent{pieces: make(map[int]*memoryPiece)}
+	return storage.TorrentImpl{Piece: t.Piece, Close: t.Close}, nil
+}
+
+func (m *memoryStorage) Close() error { return nil }
+
+type memoryTorrent struct {
+	mu     sync.Mutex
+	pieces map[int]*memoryPiece
+}
+
+func (t *memoryTorrent) Piece(p metainfo.Piece) storage.PieceImpl {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	idx := p.Index()
+	mp, ok := t.pieces[idx]
+	if !ok {
+		mp = &memoryPiece{data: make([]byte, p.Length())}
+		t.pieces[idx] = mp
+	}
+	return mp
+}
+
+func (t *memoryTorrent) Close() error { return nil }
+
+// memoryPiece implements storage.PieceImpl over an in-memory byte slice.
+type memoryPiece struct {
+	mu       sync.Mutex
+	data     []byte
+	complete bool
+}
+
+func (p *memoryPiece) ReadAt(b []byte, off int64) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if off >= int64(len(p.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, p.data[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (p *memoryPiece) WriteAt(b []byte, off int64) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return copy(p.data[off:], b), nil
+}
+
+func (p *memoryPiece) MarkComplete() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.complete = true
+	return nil
+}
+
+func (p *memoryPiece) MarkNotComplete() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.complete = false
+	return nil
+}
+
+func (p *memoryPiece) Completion() storage.Completion {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return storage.Completion{Complete: p.complete, Ok: true}
+}
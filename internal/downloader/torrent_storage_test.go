@@ -0,0 +1,96 @@
+package downloader
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+func singlePieceInfo(name string, data []byte) *metainfo.Info {
+	hash := sha1.Sum(data)
+	return &metainfo.Info{
+		PieceLength: int64(len(data)),
+		Pieces:      hash[:],
+		Name:        name,
+		Length:      int64(len(data)),
+	}
+}
+
+// TestPieceFileStorageAssemblesAndDedups covers the "piece" BTStorage kind:
+// a piece written and marked complete should end up both content-addressed
+// under .pieces and copied into its named destination file, and a second
+// torrent that happens to share the exact same piece content should dedup
+// against the first rather than writing its own copy.
+func TestPieceFileStorageAssemblesAndDedups(t *testing.T) {
+	dataDir := t.TempDir()
+	data := []byte("abcd")
+
+	clientImpl, completion, err := newPieceFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("newPieceFileStorage: %v", err)
+	}
+	defer completion.Close()
+
+	infoA := singlePieceInfo("a.bin", data)
+	tiA, err := clientImpl.OpenTorrent(infoA, metainfo.Hash{0: 1})
+	if err != nil {
+		t.Fatalf("OpenTorrent A: %v", err)
+	}
+	pieceA := tiA.Piece(infoA.Piece(0))
+
+	if _, err := pieceA.WriteAt(data, 0); err != nil {
+		t.Fatalf("WriteAt A: %v", err)
+	}
+	if err := pieceA.MarkComplete(); err != nil {
+		t.Fatalf("MarkComplete A: %v", err)
+	}
+	if !pieceA.Completion().Complete {
+		t.Fatal("expected piece A to report complete")
+	}
+
+	assembledA, err := os.ReadFile(filepath.Join(dataDir, "a.bin"))
+	if err != nil {
+		t.Fatalf("reading assembled file A: %v", err)
+	}
+	if string(assembledA) != string(data) {
+		t.Fatalf("assembled file A = %q, want %q", assembledA, data)
+	}
+
+	hash := sha1.Sum(data)
+	contentPath := filepath.Join(dataDir, ".pieces", hex.EncodeToString(hash[:]))
+	if _, err := os.Stat(contentPath); err != nil {
+		t.Fatalf("expected content-addressed piece file to exist: %v", err)
+	}
+
+	// A second torrent with different identity but the exact same piece
+	// content should dedup: its WriteAt is a no-op against the existing
+	// content-addressed file, but MarkComplete still assembles its own
+	// (differently named) destination file from it.
+	infoB := singlePieceInfo("b.bin", data)
+	tiB, err := clientImpl.OpenTorrent(infoB, metainfo.Hash{0: 2})
+	if err != nil {
+		t.Fatalf("OpenTorrent B: %v", err)
+	}
+	pieceB := tiB.Piece(infoB.Piece(0))
+
+	garbage := []byte("zzzz")
+	n, err := pieceB.WriteAt(garbage, 0)
+	if err != nil || n != len(garbage) {
+		t.Fatalf("WriteAt B: n=%d err=%v", n, err)
+	}
+	if err := pieceB.MarkComplete(); err != nil {
+		t.Fatalf("MarkComplete B: %v", err)
+	}
+
+	assembledB, err := os.ReadFile(filepath.Join(dataDir, "b.bin"))
+	if err != nil {
+		t.Fatalf("reading assembled file B: %v", err)
+	}
+	if string(assembledB) != string(data) {
+		t.Fatalf("assembled file B = %q, want %q (deduped content, not %q)", assembledB, data, garbage)
+	}
+}
@@ -0,0 +1,144 @@
+package downloader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// runStream streams d's target file to outPath (or stdout, for "-") while
+// driving piece priorities from the current read offset instead of handing
+// the whole torrent to t.DownloadAll(). It blocks until the stream reaches
+// EOF or a read/write fails.
+func (d *TorrentDownloader) runStream(t *torrent.Torrent, info *metainfo.Info) error {
+	file := d.pickStreamFile(t)
+	if file == nil {
+		return fmt.Errorf("no file available to stream")
+	}
+
+	readaheadBytes := d.readahead
+	if readaheadBytes <= 0 {
+		readaheadBytes = info.PieceLength * 4
+	}
+	readaheadPieces := int((readaheadBytes + info.PieceLength - 1) / info.PieceLength) // ceil
+
+	var out io.Writer
+	if d.outPath == "" || d.outPath == "-" {
+		out = os.Stdout
+	} else {
+		f, err := openStreamOutput(d.outPath)
+		if err != nil {
+			return fmt.Errorf("failed to open stream output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	reader := file.NewReader()
+	defer reader.Close()
+
+	fileOffset := file.Offset()
+	lastPiece := -1
+	buf := make([]byte, 256*1024)
+
+	for {
+		pos, err := reader.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("stream seek failed: %w", err)
+		}
+		headPiece := int((fileOffset + pos) / info.PieceLength)
+		if headPiece != lastPiece {
+			d.updateStreamPriorities(t, headPiece, readaheadPieces)
+			lastPiece = headPiece
+			if d.reporter != nil {
+				d.reporter.Report(map[string]interface{}{
+					"type":        "torrent",
+					"status":      "streaming",
+					"piece":       headPiece,
+					"piece_count": t.NumPieces(),
+				})
+			}
+		}
+
+		n, readErr := reader.Read(buf)
+		if n > 0 {
+			if _, writeErr := out.Write(buf[:n]); writeErr != nil {
+				return fmt.Errorf("stream write failed: %w", writeErr)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("stream read failed: %w", readErr)
+		}
+	}
+}
+
+// openStreamOutput opens path for writing a stream to, the same as
+// os.Create, except when path already exists as a named pipe (e.g. one a
+// caller mkfifo'd before running so they could start reading from it
+// independently of when this process happens to start writing) - O_CREATE
+// with O_TRUNC fails on a FIFO, and opening O_RDWR would pair a reader and
+// writer end in the same process instead of blocking for a real consumer
+// like ffmpeg/mpv on the other end.
+func openStreamOutput(path string) (*os.File, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return os.OpenFile(path, os.O_WRONLY, 0)
+	}
+	return os.Create(path)
+}
+
+// updateStreamPriorities sets the piece at headPiece to PiecePriorityNow, the
+// next one to PiecePriorityNext, the following readaheadPieces to
+// PiecePriorityReadahead, and every other piece to Normal (or None when
+// streamOnly is set, so nothing outside the read window downloads at all).
+func (d *TorrentDownloader) updateStreamPriorities(t *torrent.Torrent, headPiece, readaheadPieces int) {
+	base := torrent.PiecePriorityNormal
+	if d.streamOnly {
+		base = torrent.PiecePriorityNone
+	}
+	for i := 0; i < t.NumPieces(); i++ {
+		switch {
+		case i == headPiece:
+			t.Piece(i).SetPriority(torrent.PiecePriorityNow)
+		case i == headPiece+1:
+			t.Piece(i).SetPriority(torrent.PiecePriorityNext)
+		case i > headPiece+1 && i <= headPiece+1+readaheadPieces:
+			t.Piece(i).SetPriority(torrent.PiecePriorityReadahead)
+		default:
+			t.Piece(i).SetPriority(base)
+		}
+	}
+}
+
+// pickStreamFile returns the file to stream: the one whose path matches or
+// ends with d.streamFile if set, otherwise the largest file in the torrent.
+func (d *TorrentDownloader) pickStreamFile(t *torrent.Torrent) *torrent.File {
+	files := t.Files()
+	if len(files) == 0 {
+		return nil
+	}
+
+	if d.streamFile != "" {
+		for _, f := range files {
+			path := filepath.Join(f.FileInfo().Path...)
+			if path == d.streamFile || strings.HasSuffix(path, d.streamFile) {
+				return f
+			}
+		}
+	}
+
+	largest := files[0]
+	for _, f := range files[1:] {
+		if f.FileInfo().Length > largest.FileInfo().Length {
+			largest = f
+		}
+	}
+	return largest
+}
@@ -0,0 +1,47 @@
+package downloader
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// applyTransportConfig tunes an already-constructed http.Transport in place
+// according to cfg. It's a no-op when cfg is nil, so every downloader keeps
+// working with Go's defaults until a caller opts in.
+func applyTransportConfig(transport *http.Transport, cfg *TransportConfig) {
+	if cfg == nil {
+		return
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.DialKeepAlive,
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.DisableNoDelay {
+			if tcpConn, ok := conn.(*net.TCPConn); ok {
+				tcpConn.SetNoDelay(false)
+			}
+		}
+		return conn, nil
+	}
+
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+	if cfg.TLSConfig != nil {
+		transport.TLSClientConfig = cfg.TLSConfig
+	}
+	transport.ForceAttemptHTTP2 = cfg.ForceHTTP2
+}